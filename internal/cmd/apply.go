@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <planfile>",
+	Short: "Apply a saved migration plan",
+	Long: `Apply a plan created by 'janus plan'.
+
+The plan's environment is used. Apply refuses to run if the database version
+or any migration file checksum differs from what was recorded in the plan.
+
+Examples:
+  janus plan --env=prod -o plan.json
+  janus apply plan.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	p, err := migrator.ReadPlanFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	if cmd != nil && cmd.Flags().Changed("env") && envName != p.Environment {
+		return fmt.Errorf("plan is for environment %q, but --env=%s was given", p.Environment, envName)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigratorToApply(cfg, p.Environment)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
+	if err := mg.VerifyPlan(p); err != nil {
+		return fmt.Errorf("plan is stale, re-run 'janus plan': %w", err)
+	}
+
+	printPlan(p)
+	if p.Direction == migrator.DirectionNone {
+		return nil
+	}
+	fmt.Fprintln(ui.Output)
+
+	// Confirmation logic
+	if !AutoApprove() {
+		details := fmt.Sprintf("Applying plan from %d to %d (%s, %d migrations)",
+			p.CurrentVersion, p.TargetVersion, directionLabel(p.Direction), len(p.Migrations))
+
+		if mg.RequiresConfirmation() {
+			confirmed, err := ui.ConfirmProduction(p.Environment)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		} else {
+			confirmed, err := ui.ConfirmDangerous("apply", details)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+	}
+
+	if err := runInterruptible(mg, func() error { return mg.ApplyPlan(p) }); err != nil {
+		if isInterrupted(err) {
+			return err
+		}
+		return fmt.Errorf("apply failed: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Applied plan: now at version %d", p.TargetVersion))
+	writeSchemaSnapshot(mg)
+	return nil
+}
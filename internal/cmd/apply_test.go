@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyCmd_Registered(t *testing.T) {
+	// Verify apply command is registered
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "apply <planfile>" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("apply command not registered")
+	}
+}
+
+func TestApplyCmd_RequiresArg(t *testing.T) {
+	// apply requires exactly 1 argument
+	if applyCmd.Args == nil {
+		t.Error("apply command should have Args validator")
+	}
+}
+
+func TestApplyCmd_MissingPlanFile(t *testing.T) {
+	err := runApply(applyCmd, []string{filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil {
+		t.Error("expected error for missing plan file")
+	}
+}
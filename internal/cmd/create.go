@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,7 +11,10 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
 )
 
 var createCmd = &cobra.Command{
@@ -18,17 +22,27 @@ var createCmd = &cobra.Command{
 	Short: "Create a new migration file",
 	Long: `Create a new migration file with up/down template.
 
+With --from-schema, the desired schema file is loaded into a scratch
+database (shadow_database_url, or a temporary SQLite file for SQLite
+environments) and compared with the environment's database. The UP and a
+best-effort DOWN section are generated from the differences.
+
 Examples:
   janus create create_users_table
-  janus create add_email_to_users`,
+  janus create add_email_to_users
+  janus create add_orders --from-schema=schema.sql --env=dev`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCreate,
 }
 
-var createSeq bool
+var (
+	createSeq        bool
+	createFromSchema string
+)
 
 func init() {
 	createCmd.Flags().BoolVar(&createSeq, "seq", true, "Use sequential versioning (vs timestamp)")
+	createCmd.Flags().StringVar(&createFromSchema, "from-schema", "", "Generate the migration from the difference between this schema file and the environment's database")
 	rootCmd.AddCommand(createCmd)
 }
 
@@ -46,8 +60,38 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("migration name too long (max 100 chars)")
 	}
 
-	// Get migrations path from config
-	migrationsPath := viper.GetString("defaults.migrations_path")
+	// Without a config file migrations go to ./migrations
+	cfg, err := loadConfig()
+	if err != nil && (createFromSchema != "" || !errors.Is(err, config.ErrNotFound)) {
+		return err
+	}
+
+	content := migrationTemplate(name)
+	if createFromSchema != "" {
+		desired, err := os.ReadFile(createFromSchema)
+		if err != nil {
+			return fmt.Errorf("read schema file: %w", err)
+		}
+		sm, err := migrator.MigrationFromSchema(cfg, envName, string(desired))
+		if err != nil {
+			return err
+		}
+		if len(sm.Differences) == 0 {
+			ui.Info(fmt.Sprintf("Environment %s already matches %s; no migration created", envName, createFromSchema))
+			return nil
+		}
+		content = schemaMigrationTemplate(name, createFromSchema, sm)
+	}
+
+	// Get migrations path from config: the --env environment's, else the
+	// defaults'
+	migrationsPath := ""
+	if cfg != nil {
+		migrationsPath = cfg.Defaults.MigrationsPath
+		if env, err := cfg.Env(envName); err == nil {
+			migrationsPath = env.MigrationsPath
+		}
+	}
 	if migrationsPath == "" {
 		migrationsPath = "./migrations"
 	}
@@ -84,13 +128,15 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid file path: path traversal detected")
 	}
 
-	content := migrationTemplate(name)
 	// Security: owner read/write only (0600) for migration files
 	if err := os.WriteFile(fpath, []byte(content), 0600); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
 
 	fmt.Printf("Created: %s\n", fpath)
+	if createFromSchema != "" && strings.Contains(content, "-- TODO:") {
+		ui.Warning("Some changes could not be generated; edit the TODO lines before applying")
+	}
 	return nil
 }
 
@@ -132,3 +178,18 @@ func migrationTemplate(name string) string {
 
 `, name, time.Now().Format("2006-01-02 15:04:05"))
 }
+
+func schemaMigrationTemplate(name, schemaFile string, sm *migrator.SchemaMigration) string {
+	return fmt.Sprintf(`-- Migration: %s
+-- Created: %s
+-- Generated from %s against environment %s; review before applying.
+-- DOWN is best effort: it cannot bring back dropped data.
+
+-- +migrate UP
+%s
+
+-- +migrate DOWN
+%s
+`, name, time.Now().Format("2006-01-02 15:04:05"), filepath.Base(schemaFile), envName,
+		strings.Join(sm.Up, "\n"), strings.Join(sm.Down, "\n"))
+}
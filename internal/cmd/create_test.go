@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
 )
 
 func TestSanitizeName(t *testing.T) {
@@ -96,7 +98,7 @@ func TestRunCreate_Integration(t *testing.T) {
 	_ = os.MkdirAll(migrationsDir, 0755)
 
 	// Test that runCreate creates a file
-	// Note: This test uses the default migrations path from viper
+	// Note: This test uses the default migrations path from config
 	// The actual file creation is tested indirectly through helper functions
 	content := migrationTemplate("test_migration")
 	testFile := filepath.Join(migrationsDir, "000001_test.sql")
@@ -114,3 +116,59 @@ func TestRunCreate_Integration(t *testing.T) {
 		t.Error("Migration file should contain UP marker")
 	}
 }
+
+func TestSchemaMigrationTemplate(t *testing.T) {
+	sm := &migrator.SchemaMigration{
+		Up:   []string{"ALTER TABLE users ADD COLUMN name TEXT;"},
+		Down: []string{"ALTER TABLE users DROP COLUMN name;"},
+	}
+	content := schemaMigrationTemplate("add_name", "db/schema.sql", sm)
+
+	if !strings.Contains(content, "-- Generated from schema.sql") {
+		t.Error("template should name the schema file")
+	}
+	if !strings.Contains(content, "-- +migrate UP\nALTER TABLE users ADD COLUMN name TEXT;\n") {
+		t.Errorf("template should contain the UP statements:\n%s", content)
+	}
+	if !strings.Contains(content, "-- +migrate DOWN\nALTER TABLE users DROP COLUMN name;\n") {
+		t.Errorf("template should contain the DOWN statements:\n%s", content)
+	}
+}
+
+func TestRunCreate_FromSchema(t *testing.T) {
+	oldEnvName, oldFromSchema := envName, createFromSchema
+	defer func() { envName, createFromSchema = oldEnvName, oldFromSchema }()
+	envName = "dev"
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	schemaFile := filepath.Join(dir, "schema.sql")
+	_ = os.WriteFile(schemaFile, []byte("CREATE TABLE users (id INTEGER, email TEXT NOT NULL);"), 0644)
+	createFromSchema = schemaFile
+
+	useConfig(t, map[string]interface{}{
+		"defaults": map[string]interface{}{"migrations_path": migrationsDir},
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "sqlite3://" + filepath.Join(dir, "dev.db"),
+				"migrations_path": migrationsDir,
+			},
+		},
+	})
+
+	if err := runCreate(createCmd, []string{"create_users"}); err != nil {
+		t.Fatalf("runCreate() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(migrationsDir, "000001_create_users.sql"))
+	if err != nil {
+		t.Fatalf("migration not created: %v", err)
+	}
+	if !strings.Contains(string(data), "CREATE TABLE users (\n  id INTEGER,\n  email TEXT NOT NULL\n);") {
+		t.Errorf("unexpected UP section:\n%s", data)
+	}
+	if !strings.Contains(string(data), "DROP TABLE IF EXISTS users;") {
+		t.Errorf("unexpected DOWN section:\n%s", data)
+	}
+}
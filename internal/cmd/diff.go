@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/schema"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var diffAgainst string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the schema of two environments",
+	Long: `Introspect the databases of two environments and report how the schema
+of --env differs from the schema of --against: missing or extra tables,
+columns, indexes, constraints and views, and changed definitions such as
+column types.
+
+Both databases are only read. Exits with an error when the schemas differ,
+so it can gate a pipeline on drift.
+
+Examples:
+  janus diff --env=staging --against=prod
+  janus diff --env=staging --against=prod --output=json`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffAgainst, "against", "", "Environment to compare against (required)")
+	_ = diffCmd.MarkFlagRequired("against")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffOutput is the --output json/yaml shape of diff
+type diffOutput struct {
+	Environment string              `json:"env" yaml:"env"`
+	Against     string              `json:"against" yaml:"against"`
+	Identical   bool                `json:"identical" yaml:"identical"`
+	Differences []schema.Difference `json:"differences" yaml:"differences"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+	if diffAgainst == envName {
+		return fmt.Errorf("--against must name a different environment than --env")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	actual, actualDialect, err := migrator.InspectSchema(cfg, envName)
+	if err != nil {
+		return fmt.Errorf("environment %s: %w", envName, err)
+	}
+	expected, expectedDialect, err := migrator.InspectSchema(cfg, diffAgainst)
+	if err != nil {
+		return fmt.Errorf("environment %s: %w", diffAgainst, err)
+	}
+	if actualDialect != expectedDialect {
+		return fmt.Errorf("cannot compare a %s database (%s) with a %s database (%s)",
+			actualDialect, envName, expectedDialect, diffAgainst)
+	}
+
+	diffs := schema.Diff(expected, actual)
+
+	if structured {
+		out := diffOutput{
+			Environment: envName,
+			Against:     diffAgainst,
+			Identical:   len(diffs) == 0,
+			Differences: diffs,
+		}
+		if out.Differences == nil {
+			out.Differences = []schema.Difference{}
+		}
+		if err := writeStructured(out); err != nil {
+			return err
+		}
+	} else {
+		printDiff(diffs)
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("schemas differ: %d difference(s)", len(diffs))
+	}
+	return nil
+}
+
+func printDiff(diffs []schema.Difference) {
+	fmt.Printf("Comparing %s against %s\n\n", envName, diffAgainst)
+	if len(diffs) == 0 {
+		ui.Success("Schemas are identical")
+		return
+	}
+
+	for _, d := range diffs {
+		fmt.Printf("  %-8s %-10s %s\n", d.Kind, d.Object, d.QualifiedName())
+		switch d.Kind {
+		case schema.Missing:
+			printDiffDefinition(diffAgainst, d.Expected)
+		case schema.Extra:
+			printDiffDefinition(envName, d.Actual)
+		case schema.Changed:
+			printDiffDefinition(diffAgainst, d.Expected)
+			printDiffDefinition(envName, d.Actual)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("%d difference(s): missing = only in %s, extra = only in %s\n", len(diffs), diffAgainst, envName)
+}
+
+func printDiffDefinition(env, def string) {
+	if def == "" {
+		return
+	}
+	fmt.Printf("           %s: %s\n", env, truncate(def, 100))
+}
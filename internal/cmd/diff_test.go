@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/schema"
+)
+
+func TestDiffCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "diff" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("diff command not registered")
+	}
+}
+
+func TestDiffCmd_Flags(t *testing.T) {
+	flag := diffCmd.Flags().Lookup("against")
+	if flag == nil {
+		t.Fatal("against flag not found")
+	}
+	if _, ok := flag.Annotations["cobra_annotation_bash_completion_one_required_flag"]; !ok {
+		t.Error("against flag should be required")
+	}
+}
+
+func TestRunDiff_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	oldEnvName, oldAgainst := envName, diffAgainst
+	defer func() { envName, diffAgainst = oldEnvName, oldAgainst }()
+	envName, diffAgainst = "staging", "prod"
+
+	if err := runDiff(diffCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
+
+func TestRunDiff_SameEnv(t *testing.T) {
+	oldEnvName, oldAgainst := envName, diffAgainst
+	defer func() { envName, diffAgainst = oldEnvName, oldAgainst }()
+	envName, diffAgainst = "prod", "prod"
+
+	if err := runDiff(diffCmd, []string{}); err == nil {
+		t.Error("expected error comparing an environment with itself")
+	}
+}
+
+func TestRunDiff_JSONOutput(t *testing.T) {
+	oldEnvName, oldAgainst, oldOutput := envName, diffAgainst, outputFormat
+	defer func() { envName, diffAgainst, outputFormat = oldEnvName, oldAgainst, oldOutput }()
+	envName, diffAgainst = "staging", "prod"
+	outputFormat = outputJSON
+
+	dir := t.TempDir()
+	createDB := func(name, ddl string) string {
+		path := filepath.Join(dir, name)
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = db.Close() }()
+		if _, err := db.Exec(ddl); err != nil {
+			t.Fatal(err)
+		}
+		return "sqlite3://" + path
+	}
+	stagingURL := createDB("staging.db", "CREATE TABLE users (id INTEGER, email TEXT); CREATE INDEX idx_hotfix ON users (email);")
+	prodURL := createDB("prod.db", "CREATE TABLE users (id INTEGER, email TEXT NOT NULL);")
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"staging": map[string]interface{}{"database_url": stagingURL, "migrations_path": dir},
+			"prod":    map[string]interface{}{"database_url": prodURL, "migrations_path": dir},
+		},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runDiff(diffCmd, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err == nil {
+		t.Error("expected error when schemas differ")
+	}
+
+	var result diffOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if result.Identical || len(result.Differences) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if d := result.Differences[0]; d.Kind != schema.Changed || d.QualifiedName() != "users.email" || d.Expected != "TEXT NOT NULL" {
+		t.Errorf("unexpected column difference: %+v", d)
+	}
+	if d := result.Differences[1]; d.Kind != schema.Extra || d.Object != schema.ObjectIndex {
+		t.Errorf("unexpected index difference: %+v", d)
+	}
+}
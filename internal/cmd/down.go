@@ -6,7 +6,6 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/spf13/cobra"
 
-	"github.com/cesc1802/janus/internal/migrator"
 	"github.com/cesc1802/janus/internal/ui"
 )
 
@@ -25,12 +24,21 @@ func init() {
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
-	mg, err := migrator.New(envName)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = mg.Close() }()
 
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
 	// Get status before rollback
 	status, err := mg.Status()
 	if err != nil {
@@ -43,10 +51,10 @@ func runDown(cmd *cobra.Command, args []string) error {
 	}
 
 	// Show what will happen
-	fmt.Printf("Environment: %s\n", envName)
-	fmt.Printf("Current version: %d\n", status.Version)
-	fmt.Printf("Will rollback: %d migration(s)\n", downSteps)
-	fmt.Println()
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", status.Version)
+	fmt.Fprintf(ui.Output, "Will rollback: %d migration(s)\n", downSteps)
+	fmt.Fprintln(ui.Output)
 
 	// Confirmation logic
 	if !AutoApprove() {
@@ -73,11 +81,14 @@ func runDown(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := mg.Down(downSteps); err != nil {
+	if err := runInterruptible(mg, func() error { return mg.Down(downSteps) }); err != nil {
 		if err == migrate.ErrNoChange {
 			ui.Info("No migrations to rollback")
 			return nil
 		}
+		if isInterrupted(err) {
+			return err
+		}
 		return fmt.Errorf("rollback failed: %w", err)
 	}
 
@@ -90,10 +101,11 @@ func runDown(cmd *cobra.Command, args []string) error {
 	rolledBack := status.Applied - newStatus.Applied
 	ui.Success(fmt.Sprintf("Rolled back %d migration(s)", rolledBack))
 	if newStatus.Version > 0 {
-		fmt.Printf("Current version: %d\n", newStatus.Version)
+		fmt.Fprintf(ui.Output, "Current version: %d\n", newStatus.Version)
 	} else {
-		fmt.Println("Current version: none (clean slate)")
+		fmt.Fprintln(ui.Output, "Current version: none (clean slate)")
 	}
+	writeSchemaSnapshot(mg)
 
 	return nil
 }
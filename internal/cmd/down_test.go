@@ -3,10 +3,6 @@ package cmd
 import (
 	"bytes"
 	"testing"
-
-	"github.com/spf13/viper"
-
-	"github.com/cesc1802/janus/internal/config"
 )
 
 func TestDownCmd_Registered(t *testing.T) {
@@ -35,8 +31,7 @@ func TestDownCmd_Flags(t *testing.T) {
 }
 
 func TestDownCmd_NoConfig(t *testing.T) {
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	envName = "test"
 
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+// runFleetUp rolls pending migrations out to every target of a fleet
+// environment
+func runFleetUp(cmd *cobra.Command, cfg *config.Config) error {
+	opts, err := fleetOptions(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	rollout, err := migrator.NewRollout(cfg, envName, opts)
+	if err != nil {
+		return err
+	}
+	opts = rollout.Options()
+	targets := rollout.Targets()
+
+	fmt.Printf("Environment: %s (%d targets)\n", envName, len(targets))
+	fmt.Printf("Canary: %d, then %d at a time\n", min(opts.Canary, len(targets)), opts.Parallelism)
+	fmt.Printf("Stops after: a canary failure or more than %d failed target(s)\n", opts.MaxFailures)
+	fmt.Println()
+
+	if !AutoApprove() {
+		var confirmed bool
+		if rollout.RequiresConfirmation() {
+			confirmed, err = ui.ConfirmProduction(envName)
+		} else {
+			confirmed, err = ui.Confirm(fmt.Sprintf("Migrate %d target(s)?", len(targets)), false)
+		}
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ui.Warning("Cancelled")
+			return nil
+		}
+	}
+
+	done := 0
+	var results []migrator.TargetResult
+	err = runRolloutInterruptible(rollout, func() error {
+		var runErr error
+		results, runErr = rollout.Run(func(r migrator.TargetResult) {
+			done++
+			printTargetProgress(done, len(targets), r)
+		})
+		return runErr
+	})
+
+	fmt.Println()
+	printFleetSummary(results)
+
+	if err == nil && rollout.Stopped() {
+		applied := 0
+		for _, r := range results {
+			applied += r.Applied
+		}
+		return &interruptedError{completed: applied}
+	}
+	return err
+}
+
+// fleetOptions returns the configured rollout options, overridden by the
+// flags given on the command line
+func fleetOptions(cmd *cobra.Command, cfg *config.Config) (migrator.RolloutOptions, error) {
+	opts, err := migrator.FleetOptions(cfg, envName)
+	if err != nil {
+		return opts, err
+	}
+	opts.Steps = upSteps
+	if cmd.Flags().Changed("canary") {
+		opts.Canary = upCanary
+	}
+	if cmd.Flags().Changed("parallelism") {
+		opts.Parallelism = upParallelism
+	}
+	if cmd.Flags().Changed("max-failures") {
+		opts.MaxFailures = upMaxFailures
+	}
+	if opts.Canary < 0 || opts.Parallelism < 1 || opts.MaxFailures < 0 {
+		return opts, fmt.Errorf("--canary and --max-failures must be >= 0 and --parallelism >= 1")
+	}
+	return opts, nil
+}
+
+// runRolloutInterruptible runs fn while translating SIGINT/SIGTERM into a
+// graceful stop of the rollout: no new target starts and running targets
+// stop after their current migration. A second signal aborts the process.
+// The caller reports the stop; see Rollout.Stopped.
+func runRolloutInterruptible(r *migrator.Rollout, fn func() error) error {
+	sigs := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(sigs)
+		close(done)
+	}()
+
+	go func() {
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		fmt.Println()
+		ui.Warning("Interrupt received: stopping running targets after their current migration (press Ctrl-C again to abort)")
+		r.Stop()
+
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		ui.Error("Aborted: targets may be left in a dirty state (check 'janus status' on each)")
+		os.Exit(ExitCodeAborted)
+	}()
+
+	return fn()
+}
+
+func printTargetProgress(done, total int, r migrator.TargetResult) {
+	prefix := fmt.Sprintf("[%d/%d] %s", done, total, r.Target)
+	if r.Canary {
+		prefix += " (canary)"
+	}
+	switch r.Outcome {
+	case migrator.TargetFailed:
+		fmt.Printf("%s: %s %s\n", prefix, ui.Colorize(ui.ColorRed, "failed:"), truncate(r.Error, 120))
+	case migrator.TargetMigrated, migrator.TargetStopped:
+		fmt.Printf("%s: %s %d -> %d (%s)\n", prefix, r.Outcome, r.From, r.To, r.Duration.Round(time.Millisecond))
+	default:
+		fmt.Printf("%s: %s at %d\n", prefix, r.Outcome, r.To)
+	}
+}
+
+func printFleetSummary(results []migrator.TargetResult) {
+	width := len("TARGET")
+	counts := map[string]int{}
+	for _, r := range results {
+		width = max(width, len(r.Target))
+		counts[r.Outcome]++
+	}
+
+	fmt.Printf("%-*s  %-10s  %-8s  %-8s  %s\n", width, "TARGET", "OUTCOME", "FROM", "TO", "ERROR")
+	for _, r := range results {
+		from, to := "-", "-"
+		if r.Outcome != migrator.TargetSkipped {
+			from, to = fmt.Sprintf("%d", r.From), fmt.Sprintf("%d", r.To)
+		}
+		outcome := fmt.Sprintf("%-10s", r.Outcome)
+		switch r.Outcome {
+		case migrator.TargetFailed:
+			outcome = ui.Colorize(ui.ColorRed, outcome)
+		case migrator.TargetStopped, migrator.TargetSkipped:
+			outcome = ui.Colorize(ui.ColorYellow, outcome)
+		}
+		fmt.Printf("%-*s  %s  %-8s  %-8s  %s\n", width, r.Target, outcome, from, to, truncate(r.Error, 80))
+	}
+
+	fmt.Printf("\n%d migrated, %d up-to-date, %d failed, %d stopped, %d skipped\n",
+		counts[migrator.TargetMigrated], counts[migrator.TargetCurrent], counts[migrator.TargetFailed],
+		counts[migrator.TargetStopped], counts[migrator.TargetSkipped])
+}
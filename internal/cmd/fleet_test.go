@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+func TestUpCmd_FleetFlags(t *testing.T) {
+	for _, name := range []string{"canary", "parallelism", "max-failures"} {
+		if upCmd.Flags().Lookup(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+// useFleet selects a config with a "tenants" environment whose targets are
+// SQLite databases named after them
+func useFleet(t *testing.T, names ...string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	var list []interface{}
+	for _, name := range names {
+		list = append(list, map[string]interface{}{"name": name, "database_url": "sqlite3://" + filepath.Join(dir, name+".db")})
+	}
+	useMigrations(t, usersMigration, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"tenants": map[string]interface{}{"targets": map[string]interface{}{"list": list, "parallelism": 2}},
+		},
+	})
+}
+
+func TestFleetOptions_FlagsOverrideConfig(t *testing.T) {
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "tenants"
+	useFleet(t, "acme")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().IntVar(&upCanary, "canary", 0, "")
+	cmd.Flags().IntVar(&upParallelism, "parallelism", 0, "")
+	cmd.Flags().IntVar(&upMaxFailures, "max-failures", 0, "")
+	_ = cmd.Flags().Set("max-failures", "3")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := fleetOptions(cmd, cfg)
+	if err != nil {
+		t.Fatalf("fleetOptions() error: %v", err)
+	}
+	if opts.Canary != config.DefaultCanary || opts.Parallelism != 2 || opts.MaxFailures != 3 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	_ = cmd.Flags().Set("parallelism", "0")
+	if _, err := fleetOptions(cmd, cfg); err == nil {
+		t.Error("expected error for --parallelism=0")
+	}
+}
+
+func TestRunUp_Fleet(t *testing.T) {
+	oldEnvName, oldAuto := envName, autoApprove
+	defer func() { envName, autoApprove = oldEnvName, oldAuto }()
+	envName, autoApprove = "tenants", true
+	useFleet(t, "acme", "globex", "initech")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runUp(upCmd, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runUp() error: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Environment: tenants (3 targets)", "acme (canary): migrated 0 -> 1", "TARGET", "3 migrated, 0 up-to-date"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
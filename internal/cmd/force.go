@@ -6,7 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/cesc1802/janus/internal/migrator"
 	"github.com/cesc1802/janus/internal/ui"
 )
 
@@ -36,7 +35,12 @@ func runForce(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid version: %w", err)
 	}
 
-	mg, err := migrator.New(envName)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
 	if err != nil {
 		return err
 	}
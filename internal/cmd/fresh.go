@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var freshCmd = &cobra.Command{
+	Use:   "fresh",
+	Short: "Drop all tables and re-apply every migration",
+	Long: `Drop every table in the database, including the version table, and
+apply all migrations from scratch. DOWN sections are not run, so this also
+works when a DOWN is missing or broken.
+
+Disabled for environments with require_confirmation: true unless they
+also set allow_reset: true.
+
+Examples:
+  janus fresh --env=dev
+  janus fresh --env=review --auto-approve`,
+	RunE: runFresh,
+}
+
+func init() {
+	rootCmd.AddCommand(freshCmd)
+}
+
+func runFresh(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigratorToApply(cfg, envName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	if !mg.ResetAllowed() {
+		return migrator.ErrResetNotAllowed
+	}
+
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+
+	// Show what will happen
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Current version: %d (dirty: %v)\n", status.Version, status.Dirty)
+	fmt.Fprintln(ui.Output, "Will drop: ALL tables in the database")
+	fmt.Fprintf(ui.Output, "Then apply: %d migration(s)\n", status.Total)
+	fmt.Fprintln(ui.Output)
+
+	// Confirmation logic
+	if !AutoApprove() {
+		details := fmt.Sprintf("Dropping all tables in %s and re-applying %d migration(s)\nAll data will be lost", envName, status.Total)
+
+		if mg.RequiresConfirmation() {
+			confirmed, err := ui.ConfirmProduction(envName)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		} else {
+			confirmed, err := ui.ConfirmDangerous("fresh", details)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+	}
+
+	if err := runInterruptible(mg, mg.Fresh); err != nil {
+		if isInterrupted(err) {
+			return err
+		}
+		return fmt.Errorf("fresh failed: %w", err)
+	}
+
+	newStatus, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	ui.Success(fmt.Sprintf("Applied %d migration(s) on a fresh database", newStatus.Applied))
+	fmt.Fprintf(ui.Output, "Current version: %d\n", newStatus.Version)
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFreshCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "fresh" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("fresh command not registered")
+	}
+}
+
+func TestFreshCmd_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	envName = "test"
+
+	var buf bytes.Buffer
+	freshCmd.SetOut(&buf)
+	freshCmd.SetErr(&buf)
+
+	if err := runFresh(freshCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
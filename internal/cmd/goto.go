@@ -37,12 +37,21 @@ func runGoto(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid version: %w", err)
 	}
 
-	mg, err := migrator.New(envName)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigratorToApply(cfg, envName)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = mg.Close() }()
 
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
 	// Get current status
 	status, err := mg.Status()
 	if err != nil {
@@ -52,7 +61,7 @@ func runGoto(cmd *cobra.Command, args []string) error {
 	// Check dirty state - cannot migrate if database is dirty
 	if status.Dirty {
 		ui.Warning("Database is in dirty state.")
-		fmt.Println("Use 'janus force <version>' to fix the dirty state first.")
+		fmt.Fprintln(ui.Output, "Use 'janus repair' (or 'janus force <version>') to fix the dirty state first.")
 		return fmt.Errorf("cannot migrate: database in dirty state at version %d", status.Version)
 	}
 
@@ -72,11 +81,11 @@ func runGoto(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Println("Migration Target")
-	fmt.Printf("Environment: %s\n", envName)
-	fmt.Printf("Current version: %d\n", status.Version)
-	fmt.Printf("Target version: %d\n", targetVersion)
-	fmt.Printf("Direction: %s (%d migration(s))\n\n", direction, stepsCount)
+	fmt.Fprintln(ui.Output, "Migration Target")
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", status.Version)
+	fmt.Fprintf(ui.Output, "Target version: %d\n", targetVersion)
+	fmt.Fprintf(ui.Output, "Direction: %s (%d migration(s))\n\n", direction, stepsCount)
 
 	// Confirmation logic
 	if !AutoApprove() {
@@ -103,15 +112,19 @@ func runGoto(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := mg.Goto(target); err != nil {
+	if err := runInterruptible(mg, func() error { return mg.Goto(target) }); err != nil {
 		if err == migrate.ErrNoChange {
 			ui.Info("No migrations to apply")
 			return nil
 		}
+		if isInterrupted(err) {
+			return err
+		}
 		return fmt.Errorf("goto failed: %w", err)
 	}
 
 	ui.Success(fmt.Sprintf("Migrated to version %d", targetVersion))
+	writeSchemaSnapshot(mg)
 	return nil
 }
 
@@ -4,10 +4,6 @@ import (
 	"bytes"
 	"strconv"
 	"testing"
-
-	"github.com/spf13/viper"
-
-	"github.com/cesc1802/janus/internal/config"
 )
 
 func TestGotoCmd_Registered(t *testing.T) {
@@ -61,8 +57,7 @@ func TestGotoCmd_VersionParsing(t *testing.T) {
 }
 
 func TestGotoCmd_NoConfig(t *testing.T) {
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	envName = "test"
 
@@ -22,31 +22,105 @@ func init() {
 	rootCmd.AddCommand(historyCmd)
 }
 
+// historyOutput is the --output schema for history
+type historyOutput struct {
+	Environment    string                   `json:"environment" yaml:"environment"`
+	Schema         string                   `json:"schema,omitempty" yaml:"schema,omitempty"`
+	CurrentVersion uint                     `json:"current_version" yaml:"current_version"`
+	Migrations     []migrator.MigrationInfo `json:"migrations" yaml:"migrations"`
+	// Schemas holds each schema's history for schema-per-tenant environments
+	Schemas []historyOutput `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
 func runHistory(cmd *cobra.Command, args []string) error {
-	mg, err := migrator.New(envName)
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = mg.Close() }()
 
-	status, err := mg.Status()
+	out, err := migrationHistory(mg)
 	if err != nil {
 		return err
 	}
+	for _, child := range mg.Schemas() {
+		h, err := migrationHistory(child)
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", child.SchemaName(), err)
+		}
+		out.Schemas = append(out.Schemas, h)
+	}
+
+	if structured {
+		// Machine output lists every migration unless --limit is given explicitly
+		limit := func(h *historyOutput) {
+			if cmd != nil && cmd.Flags().Changed("limit") && len(h.Migrations) > historyLimit {
+				h.Migrations = h.Migrations[:historyLimit]
+			}
+			if h.Migrations == nil {
+				h.Migrations = []migrator.MigrationInfo{}
+			}
+		}
+		limit(&out)
+		for i := range out.Schemas {
+			limit(&out.Schemas[i])
+		}
+		return writeStructured(out)
+	}
+
+	if len(out.Schemas) == 0 {
+		printHistory(out)
+		return nil
+	}
+	for i, h := range out.Schemas {
+		if i > 0 {
+			fmt.Println()
+		}
+		printHistory(h)
+	}
+	return nil
+}
 
-	migrations := mg.GetMigrationList(status.Version)
+// migrationHistory lists the migrations of mg with their applied status
+func migrationHistory(mg *migrator.Migrator) (historyOutput, error) {
+	status, err := mg.Status()
+	if err != nil {
+		return historyOutput{}, err
+	}
+	return historyOutput{
+		Environment:    mg.EnvName(),
+		Schema:         mg.SchemaName(),
+		CurrentVersion: status.Version,
+		Migrations:     mg.GetMigrationList(status.Version),
+	}, nil
+}
 
-	fmt.Printf("Migration History (env: %s)\n", envName)
+func printHistory(h historyOutput) {
+	if h.Schema != "" {
+		fmt.Printf("Migration History (env: %s, schema: %s)\n", h.Environment, h.Schema)
+	} else {
+		fmt.Printf("Migration History (env: %s)\n", h.Environment)
+	}
 	fmt.Println("----------------------------------------")
 
-	if len(migrations) == 0 {
+	if len(h.Migrations) == 0 {
 		fmt.Println("  No migrations found")
-		return nil
+		return
 	}
 
 	// Show up to limit migrations
 	shown := 0
-	for _, m := range migrations {
+	for _, m := range h.Migrations {
 		if shown >= historyLimit {
 			break
 		}
@@ -59,9 +133,7 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		shown++
 	}
 
-	if len(migrations) > historyLimit {
-		fmt.Printf("\n  ... and %d more (use --limit to show more)\n", len(migrations)-historyLimit)
+	if len(h.Migrations) > historyLimit {
+		fmt.Printf("\n  ... and %d more (use --limit to show more)\n", len(h.Migrations)-historyLimit)
 	}
-
-	return nil
 }
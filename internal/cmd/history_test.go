@@ -3,10 +3,6 @@ package cmd
 import (
 	"bytes"
 	"testing"
-
-	"github.com/spf13/viper"
-
-	"github.com/cesc1802/janus/internal/config"
 )
 
 func TestHistoryCmd_Registered(t *testing.T) {
@@ -35,8 +31,7 @@ func TestHistoryCmd_Flags(t *testing.T) {
 }
 
 func TestHistoryCmd_NoConfig(t *testing.T) {
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	envName = "test"
 
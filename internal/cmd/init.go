@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up janus in the current project",
+	Long: `Write a commented janus.yaml, create the migrations directory with an
+example migration, and optionally add janus.local.yaml and .janus/ to
+.gitignore.
+
+init asks which driver and environments to set up and which environments
+require confirmation. Each question is skipped when its flag is given; with
+--auto-approve the remaining ones take their defaults, so init can run
+without a terminal.
+
+Examples:
+  janus init                                   # Interactive
+  janus init --driver=mysql --envs=dev,prod    # Ask the rest
+  janus init --driver=sqlite3 --envs=dev --auto-approve`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+var (
+	initDriver         string
+	initEnvs           []string
+	initConfirm        []string
+	initMigrationsPath string
+	initGitignore      bool
+	initForce          bool
+)
+
+// initDrivers are the drivers init offers, in prompt order
+var initDrivers = []string{config.DriverPostgres, config.DriverMySQL, config.DriverSQLite}
+
+// localConfigFile is the git-ignored layer for personal overrides
+const localConfigFile = "janus.local.yaml"
+
+// stateDir holds what janus records locally, such as failed migrations
+const stateDir = ".janus/"
+
+func init() {
+	initCmd.Flags().StringVar(&initDriver, "driver", config.DriverPostgres, "database driver: postgres, mysql or sqlite3")
+	initCmd.Flags().StringSliceVar(&initEnvs, "envs", []string{"dev", "staging", "prod"}, "environments to set up")
+	initCmd.Flags().StringSliceVar(&initConfirm, "confirm", nil, "environments that require confirmation (default: all but the first)")
+	initCmd.Flags().StringVar(&initMigrationsPath, "migrations-path", "./migrations", "migrations directory")
+	initCmd.Flags().BoolVar(&initGitignore, "gitignore", true, "add "+localConfigFile+" and "+stateDir+" to .gitignore")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+}
+
+// initOptions are the answers init scaffolds a project from
+type initOptions struct {
+	App            string
+	Driver         string
+	Envs           []string
+	Confirm        []string
+	MigrationsPath string
+	Gitignore      bool
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	path := cfgFile
+	if path == "" {
+		path = config.FileNames[0]
+	}
+	if _, err := os.Stat(path); err == nil && !initForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+
+	opts, err := askInitOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The template is generated; make sure janus can read it before
+	// replacing anything
+	content := initConfigTemplate(opts)
+	if _, err := config.Read(strings.NewReader(content)); err != nil {
+		return fmt.Errorf("generated config is invalid: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	ui.Success("Created " + path)
+
+	// migrations_path is relative to the config file, as janus reads it
+	migrationsDir := opts.MigrationsPath
+	if !filepath.IsAbs(migrationsDir) {
+		migrationsDir = filepath.Join(filepath.Dir(path), migrationsDir)
+	}
+	example, err := writeExampleMigration(migrationsDir, opts.Driver)
+	if err != nil {
+		return err
+	}
+	if example != "" {
+		ui.Success("Created " + example)
+	} else {
+		ui.Info(migrationsDir + " already holds migrations; no example added")
+	}
+
+	if opts.Gitignore {
+		gitignore := filepath.Join(filepath.Dir(path), ".gitignore")
+		for _, e := range []struct{ entry, comment string }{
+			{localConfigFile, "janus personal config overrides"},
+			{stateDir, "janus records of failed migrations"},
+		} {
+			added, err := addGitignoreEntry(gitignore, e.entry, e.comment)
+			if err != nil {
+				return err
+			}
+			if added {
+				ui.Success("Added " + e.entry + " to .gitignore")
+			}
+		}
+	}
+
+	fmt.Println("\nNext steps:")
+	fmt.Printf("  1. Review the database URLs in %s\n", path)
+	fmt.Printf("  2. janus up --env=%s\n", opts.Envs[0])
+	return nil
+}
+
+// askInitOptions takes each answer from its flag when given, else from a
+// prompt, or the flag's default with --auto-approve
+func askInitOptions(cmd *cobra.Command) (initOptions, error) {
+	ask := func(flag string) bool {
+		return !cmd.Flags().Changed(flag) && !AutoApprove()
+	}
+	opts := initOptions{
+		App:            appName(),
+		Driver:         initDriver,
+		Envs:           initEnvs,
+		Confirm:        initConfirm,
+		MigrationsPath: initMigrationsPath,
+		Gitignore:      initGitignore,
+	}
+
+	if ask("driver") {
+		index, err := ui.Select("Database driver", initDrivers)
+		if err != nil {
+			return opts, err
+		}
+		opts.Driver = initDrivers[index]
+	}
+	if !slices.Contains(initDrivers, opts.Driver) {
+		return opts, fmt.Errorf("invalid --driver %q (expected %s)", opts.Driver, strings.Join(initDrivers, ", "))
+	}
+
+	if ask("envs") {
+		answer, err := ui.Input("Environments (comma-separated)", strings.Join(opts.Envs, ","))
+		if err != nil {
+			return opts, err
+		}
+		opts.Envs = strings.Split(answer, ",")
+	}
+	envs, err := initEnvNames(opts.Envs)
+	if err != nil {
+		return opts, err
+	}
+	opts.Envs = envs
+
+	if ask("confirm") {
+		opts.Confirm = nil
+		for i, env := range opts.Envs {
+			required, err := ui.Confirm(fmt.Sprintf("Require confirmation before migrating %s?", env), i == 0)
+			if err != nil {
+				return opts, err
+			}
+			if required {
+				opts.Confirm = append(opts.Confirm, env)
+			}
+		}
+	} else if opts.Confirm == nil {
+		opts.Confirm = opts.Envs[1:]
+	}
+	for _, env := range opts.Confirm {
+		if !slices.Contains(opts.Envs, env) {
+			return opts, fmt.Errorf("--confirm: %q is not one of the environments %v", env, opts.Envs)
+		}
+	}
+
+	if ask("migrations-path") {
+		if opts.MigrationsPath, err = ui.Input("Migrations directory", opts.MigrationsPath); err != nil {
+			return opts, err
+		}
+	}
+
+	if ask("gitignore") {
+		if opts.Gitignore, err = ui.Confirm("Add "+localConfigFile+" (local overrides) and "+stateDir+" to .gitignore?", false); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// initEnvNames trims and checks environment names
+func initEnvNames(names []string) ([]string, error) {
+	var envs []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if sanitizeName(name) != strings.ReplaceAll(name, "-", "_") {
+			return nil, fmt.Errorf("invalid environment name %q (use lowercase letters, digits, - and _)", name)
+		}
+		if slices.Contains(envs, name) {
+			return nil, fmt.Errorf("environment %q listed twice", name)
+		}
+		envs = append(envs, name)
+	}
+	if len(envs) == 0 {
+		return nil, errors.New("at least one environment is required")
+	}
+	return envs, nil
+}
+
+// appName derives database names from the working directory's name
+func appName() string {
+	dir, err := os.Getwd()
+	if name := sanitizeName(filepath.Base(dir)); err == nil && name != "" {
+		return name
+	}
+	return "app"
+}
+
+// initDatabaseURL returns the database URL written for an environment: a
+// local database for the first one, an env var with a local fallback for
+// the others
+func initDatabaseURL(opts initOptions, env string) string {
+	db := opts.App + "_" + sanitizeName(env)
+	var local string
+	switch opts.Driver {
+	case config.DriverMySQL:
+		local = "mysql://root@tcp(localhost:3306)/" + db
+	case config.DriverSQLite:
+		local = "sqlite3://./" + db + ".db"
+	default:
+		local = "postgres://postgres@localhost:5432/" + db + "?sslmode=disable"
+	}
+	if env == opts.Envs[0] {
+		return local
+	}
+	return "${" + initEnvVar(env) + ":-" + local + "}"
+}
+
+// initEnvVar names the variable holding an environment's database URL
+func initEnvVar(env string) string {
+	return strings.ToUpper(sanitizeName(env)) + "_DATABASE_URL"
+}
+
+// initConfigTemplate renders the commented janus.yaml written by init
+func initConfigTemplate(opts initOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# janus configuration, generated by 'janus init'
+# Every key is described in config.example.yaml and docs/cli-reference.md.
+# Personal overrides go in %s (git-ignored), environment-specific
+# ones in janus.<env>.yaml; both are merged over this file.
+
+# Values every environment inherits unless it sets them
+defaults:
+  migrations_path: %q
+  # Session timeouts per migration (Postgres/MySQL; statement_timeout is
+  # Postgres only)
+  # statement_timeout: 5m
+  # lock_timeout: 10s
+
+environments:
+`, localConfigFile, opts.MigrationsPath)
+
+	for i, env := range opts.Envs {
+		fmt.Fprintf(&b, "  %s:\n", env)
+		if i == 0 {
+			b.WriteString("    # Local database; ${VAR}, ${VAR:-default} and *_file secrets also work here\n")
+		} else {
+			fmt.Fprintf(&b, "    # Export %s (or use database_url_file for a secret\n", initEnvVar(env))
+			b.WriteString("    # file); the fallback only serves local runs\n")
+		}
+		fmt.Fprintf(&b, "    database_url: %q\n", initDatabaseURL(opts, env))
+		if slices.Contains(opts.Confirm, env) {
+			b.WriteString("    # Ask before migrating; CI passes --auto-approve\n")
+			b.WriteString("    require_confirmation: true\n")
+		}
+		if i == 0 {
+			b.WriteString("    # Normalized schema dump refreshed after up, down and goto\n")
+			b.WriteString("    # schema_file: \"./schema.sql\"\n")
+		}
+	}
+
+	fmt.Fprintf(&b, `
+# Order in which changes reach environments; 'janus status --all-envs'
+# flags environments behind the one before them
+promotion_order: [%s]
+
+# Lint rule severities: error, warning (default) or off
+# lint:
+#   drop_table: error
+`, strings.Join(opts.Envs, ", "))
+	return b.String()
+}
+
+// writeExampleMigration creates the migrations directory dir with a first
+// migration for driver. Returns "" if dir already holds migrations.
+func writeExampleMigration(dir, driver string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create migrations dir: %w", err)
+	}
+	if getNextSequentialVersion(dir) != fmt.Sprintf("%06d", 1) {
+		return "", nil
+	}
+
+	idType := "BIGSERIAL PRIMARY KEY"
+	switch driver {
+	case config.DriverMySQL:
+		idType = "BIGINT AUTO_INCREMENT PRIMARY KEY"
+	case config.DriverSQLite:
+		idType = "INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	content := fmt.Sprintf(`-- Migration: create_examples
+-- Created: %s
+-- An example generated by 'janus init': edit it or delete it and run
+-- 'janus create <name>' for your first real migration.
+
+-- +migrate UP
+CREATE TABLE examples (
+    id %s,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +migrate DOWN
+DROP TABLE IF EXISTS examples;
+`, time.Now().Format("2006-01-02 15:04:05"), idType)
+
+	path := filepath.Join(dir, "000001_create_examples.sql")
+	// Owner read/write only, as for 'janus create'
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", fmt.Errorf("write example migration: %w", err)
+	}
+	return path, nil
+}
+
+// addGitignoreEntry appends entry, under a comment line, to the .gitignore
+// at path, creating it if needed. Reports false when the entry is already
+// listed, with or without leading and trailing slashes.
+func addGitignoreEntry(path, entry, comment string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read .gitignore: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Trim(strings.TrimSpace(line), "/") == strings.Trim(entry, "/") {
+			return false, nil
+		}
+	}
+
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("# " + comment + "\n" + entry + "\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return false, fmt.Errorf("write .gitignore: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// setupInit runs the test in an empty project directory with init's flags
+// at their defaults and prompts disabled
+func setupInit(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "shop-api")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	oldCfgFile, oldAuto := cfgFile, autoApprove
+	oldDriver, oldEnvs, oldConfirm := initDriver, initEnvs, initConfirm
+	oldPath, oldGitignore, oldForce := initMigrationsPath, initGitignore, initForce
+	t.Cleanup(func() {
+		cfgFile, autoApprove = oldCfgFile, oldAuto
+		initDriver, initEnvs, initConfirm = oldDriver, oldEnvs, oldConfirm
+		initMigrationsPath, initGitignore, initForce = oldPath, oldGitignore, oldForce
+	})
+	cfgFile, autoApprove = "", true
+	initDriver, initEnvs, initConfirm = config.DriverPostgres, []string{"dev", "staging", "prod"}, nil
+	initMigrationsPath, initGitignore, initForce = "./migrations", true, false
+	return dir
+}
+
+func TestRunInit(t *testing.T) {
+	for _, driver := range initDrivers {
+		t.Run(driver, func(t *testing.T) {
+			setupInit(t)
+			initDriver = driver
+
+			if err := runInit(initCmd, nil); err != nil {
+				t.Fatalf("runInit() error: %v", err)
+			}
+
+			cfg, err := config.Load("janus.yaml")
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if len(cfg.Environments) != 3 {
+				t.Fatalf("environments = %v", cfg.Environments)
+			}
+			dev, prod := cfg.Environments["dev"], cfg.Environments["prod"]
+			if !strings.Contains(dev.DatabaseURL, "shop_api_dev") {
+				t.Errorf("dev database_url = %q", dev.DatabaseURL)
+			}
+			if dev.RequireConfirmation || !prod.RequireConfirmation {
+				t.Errorf("require_confirmation dev=%v prod=%v", dev.RequireConfirmation, prod.RequireConfirmation)
+			}
+			if dev.MigrationsPath != "./migrations" {
+				t.Errorf("migrations_path = %q", dev.MigrationsPath)
+			}
+			if strings.Join(cfg.PromotionOrder, ",") != "dev,staging,prod" {
+				t.Errorf("promotion_order = %v", cfg.PromotionOrder)
+			}
+
+			if _, err := os.Stat(filepath.Join("migrations", "000001_create_examples.sql")); err != nil {
+				t.Errorf("example migration: %v", err)
+			}
+			gitignore, err := os.ReadFile(".gitignore")
+			if err != nil || !strings.Contains(string(gitignore), "janus.local.yaml\n") || !strings.Contains(string(gitignore), ".janus/\n") {
+				t.Errorf(".gitignore = %q, %v", gitignore, err)
+			}
+		})
+	}
+}
+
+func TestRunInit_EnvVarFallback(t *testing.T) {
+	setupInit(t)
+	initEnvs, initConfirm = []string{"dev", "prod-eu"}, []string{}
+
+	if err := runInit(initCmd, nil); err != nil {
+		t.Fatalf("runInit() error: %v", err)
+	}
+	data, err := os.ReadFile("janus.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "${PROD_EU_DATABASE_URL:-postgres://") {
+		t.Errorf("prod-eu database_url does not read PROD_EU_DATABASE_URL:\n%s", data)
+	}
+
+	t.Setenv("PROD_EU_DATABASE_URL", "postgres://eu.db/shop")
+	cfg, err := config.Load("janus.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod := cfg.Environments["prod-eu"]
+	if prod.DatabaseURL != "postgres://eu.db/shop" {
+		t.Errorf("prod-eu database_url = %q", prod.DatabaseURL)
+	}
+	if prod.RequireConfirmation {
+		t.Error("prod-eu requires confirmation despite an empty --confirm")
+	}
+}
+
+func TestRunInit_Existing(t *testing.T) {
+	setupInit(t)
+	if err := os.WriteFile("janus.yaml", []byte("environments: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("migrations", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("migrations", "000007_users.sql"), []byte("-- +migrate UP\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".gitignore", []byte("/bin\n/janus.local.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runInit(initCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("runInit() error = %v, want already exists", err)
+	}
+
+	initForce = true
+	if err := runInit(initCmd, nil); err != nil {
+		t.Fatalf("runInit() with --force error: %v", err)
+	}
+	entries, _ := os.ReadDir("migrations")
+	if len(entries) != 1 {
+		t.Errorf("migrations = %d files, want the existing one only", len(entries))
+	}
+	gitignore, _ := os.ReadFile(".gitignore")
+	if string(gitignore) != "/bin\n/janus.local.yaml\n# janus records of failed migrations\n.janus/\n" {
+		t.Errorf(".gitignore should only gain .janus/: %q", gitignore)
+	}
+}
+
+func TestRunInit_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr string
+	}{
+		{"driver", func() { initDriver = "oracle" }, `invalid --driver "oracle"`},
+		{"env name", func() { initEnvs = []string{"Prod"} }, `invalid environment name "Prod"`},
+		{"duplicate env", func() { initEnvs = []string{"dev", "dev"} }, `environment "dev" listed twice`},
+		{"no envs", func() { initEnvs = []string{" "} }, "at least one environment"},
+		{"unknown confirm", func() { initConfirm = []string{"qa"} }, `--confirm: "qa" is not one of the environments`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupInit(t)
+			tt.setup()
+
+			err := runInit(initCmd, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runInit() error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat("janus.yaml"); err == nil {
+				t.Error("janus.yaml written despite invalid options")
+			}
+		})
+	}
+}
+
+func TestRunInit_ConfigInSubdir(t *testing.T) {
+	setupInit(t)
+	if err := os.Mkdir("db", 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = filepath.Join("db", "janus.yaml")
+
+	if err := runInit(initCmd, nil); err != nil {
+		t.Fatalf("runInit() error: %v", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	example := filepath.Join(cfg.Environments["dev"].MigrationsPath, "000001_create_examples.sql")
+	if _, err := os.Stat(example); err != nil {
+		t.Errorf("example migration not where the config points: %v", err)
+	}
+	if _, err := os.Stat("migrations"); !os.IsNotExist(err) {
+		t.Errorf("migrations created in the working directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("db", ".gitignore")); err != nil {
+		t.Errorf(".gitignore not next to the config: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/lint"
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Flag risky operations in migration files",
+	Long: `Check each migration's UP and DOWN SQL for risky operations:
+dropped tables and columns, column type changes, NOT NULL columns added
+without a default, Postgres indexes built without CONCURRENTLY, table
+rewrites, and DROP without IF EXISTS in DOWN.
+
+Rules default to warning. Set a rule to error or off under 'lint:' in
+janus.yaml, or suppress it for one migration with a directive:
+
+  -- +migrate lint-ignore:drop_column
+
+Exits with an error when any finding has severity error. The database is
+not contacted; its URL only selects dialect-specific rules.
+
+Examples:
+  janus lint --env=prod
+  janus lint --output=json`,
+	RunE: runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+// lintOutput is the --output json/yaml shape of lint
+type lintOutput struct {
+	Environment string         `json:"env" yaml:"env"`
+	Migrations  int            `json:"migrations" yaml:"migrations"`
+	Errors      int            `json:"errors" yaml:"errors"`
+	Warnings    int            `json:"warnings" yaml:"warnings"`
+	Findings    []lint.Finding `json:"findings" yaml:"findings"`
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	findings, count, err := migrator.Lint(cfg, envName)
+	if err != nil {
+		return err
+	}
+	errs, warnings := countFindings(findings)
+
+	if structured {
+		out := lintOutput{
+			Environment: envName,
+			Migrations:  count,
+			Errors:      errs,
+			Warnings:    warnings,
+			Findings:    findings,
+		}
+		if out.Findings == nil {
+			out.Findings = []lint.Finding{}
+		}
+		if err := writeStructured(out); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Linting %d migration(s) for '%s'...\n", count, envName)
+		printFindings(findings)
+		fmt.Println()
+		if len(findings) == 0 {
+			ui.Success("No risky operations found")
+		} else {
+			fmt.Printf("%d finding(s): %d error(s), %d warning(s)\n", len(findings), errs, warnings)
+		}
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("lint failed with %d error(s)", errs)
+	}
+	return nil
+}
+
+func countFindings(findings []lint.Finding) (errs, warnings int) {
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	return errs, warnings
+}
+
+// printFindings prints findings grouped by migration
+func printFindings(findings []lint.Finding) {
+	var last uint
+	for i, f := range findings {
+		if i == 0 || f.Version != last {
+			fmt.Printf("\n%06d - %s\n", f.Version, f.Name)
+			last = f.Version
+		}
+		fmt.Printf("  %-8s %-25s %s\n", f.Severity, f.Rule, f.Message)
+		fmt.Printf("  %-8s %-25s %s: %s\n", "", "", sectionLabel(f.Section), truncate(f.Statement, 80))
+	}
+}
+
+func sectionLabel(section string) string {
+	if section == lint.SectionDown {
+		return "DOWN"
+	}
+	return "UP"
+}
+
+// lintMessage formats a finding as a single validate error or warning
+func lintMessage(env string, f lint.Finding) string {
+	return fmt.Sprintf("Env %s: %06d %s: %s: %s (%s: %s)",
+		env, f.Version, f.Name, f.Rule, f.Message, sectionLabel(f.Section), truncate(f.Statement, 60))
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestLintCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "lint" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("lint command not registered")
+	}
+}
+
+func TestRunLint_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "test"
+
+	if err := runLint(lintCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
+
+func TestRunLint_JSONOutput(t *testing.T) {
+	oldEnvName, oldOutput := envName, outputFormat
+	defer func() { envName, outputFormat = oldEnvName, oldOutput }()
+	envName = "dev"
+	outputFormat = outputJSON
+
+	// Postgres selects the index_not_concurrent rule
+	useMigrations(t, map[string]string{
+		"000001_users.sql": "-- +migrate UP\nCREATE TABLE users (id INT);\n-- +migrate DOWN\nDROP TABLE IF EXISTS users;",
+		"000002_index.sql": "-- +migrate UP\nCREATE INDEX idx_users_id ON users (id);\n-- +migrate DOWN\nDROP INDEX IF EXISTS idx_users_id;",
+	}, map[string]interface{}{
+		"environments": map[string]interface{}{"dev": map[string]interface{}{"database_url": "postgres://localhost/test"}},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runLint(lintCmd, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("warnings should not fail lint: %v", err)
+	}
+
+	var result lintOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if result.Migrations != 2 || result.Warnings != 1 || result.Errors != 0 || len(result.Findings) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if f := result.Findings[0]; f.Version != 2 || f.Rule != "index_not_concurrent" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestRunLint_ErrorSeverityFails(t *testing.T) {
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "dev"
+
+	useMigrations(t, map[string]string{
+		"000001_drop.sql": "-- +migrate UP\nDROP TABLE legacy;\n-- +migrate DOWN\nCREATE TABLE legacy (id INT);",
+	}, map[string]interface{}{"lint": map[string]interface{}{"drop_table": "error"}})
+
+	if err := runLint(lintCmd, nil); err == nil {
+		t.Error("expected error for finding with severity error")
+	}
+}
+
+func TestRunValidate_LintErrors(t *testing.T) {
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "dev"
+
+	useMigrations(t, map[string]string{
+		"000001_drop.sql": "-- +migrate UP\nDROP TABLE legacy;\n-- +migrate DOWN\nCREATE TABLE legacy (id INT);",
+	}, map[string]interface{}{"lint": map[string]interface{}{"drop_table": "error"}})
+
+	if err := runValidate(nil, nil); err == nil {
+		t.Error("validate should fail on lint errors")
+	}
+}
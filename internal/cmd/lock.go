@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Inspect and break migration locks",
+	Long: `Inspect and break the lock golang-migrate takes while migrating.
+
+A lock left behind by a crashed or hung run blocks every later migration.
+Postgres uses a session advisory lock and MySQL a named GET_LOCK; both are
+held by a database session. SQLite has no lock that outlives the process.`,
+}
+
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the migration lock",
+	Long: `Show whether the migration lock is held, by which database session,
+and since when where the database can tell.
+
+Examples:
+  janus lock status --env=prod
+  janus lock status --env=prod --output=json`,
+	RunE: runLockStatus,
+}
+
+var lockReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Break the migration lock by terminating its session",
+	Long: `Break the migration lock by terminating the database session holding it.
+
+Any migration that session is running is interrupted, which can leave the
+database dirty (check 'janus status' afterwards). Only use this when the
+holder is known to be dead or stuck.
+
+Examples:
+  janus lock release --env=prod`,
+	RunE: runLockRelease,
+}
+
+func init() {
+	lockCmd.AddCommand(lockStatusCmd)
+	lockCmd.AddCommand(lockReleaseCmd)
+	rootCmd.AddCommand(lockCmd)
+}
+
+func runLockStatus(cmd *cobra.Command, args []string) error {
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	info, err := migrator.LockStatus(cfg, envName)
+	if err != nil {
+		return fmt.Errorf("get lock status: %w", err)
+	}
+
+	if structured {
+		return writeStructured(info)
+	}
+
+	fmt.Printf("Environment: %s\n", envName)
+	switch {
+	case !info.Supported:
+		fmt.Println("Lock: none (SQLite locks are held in-process only)")
+	case !info.Held:
+		fmt.Println("Lock: free")
+	default:
+		fmt.Println("Lock: HELD")
+		printLockHolder(info.Holder)
+	}
+	return nil
+}
+
+func runLockRelease(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	info, err := migrator.LockStatus(cfg, envName)
+	if err != nil {
+		return fmt.Errorf("get lock status: %w", err)
+	}
+	if !info.Supported {
+		return fmt.Errorf("%w: nothing to release", migrator.ErrLockNotSupported)
+	}
+	if !info.Held {
+		ui.Info("Migration lock is not held")
+		return nil
+	}
+
+	fmt.Printf("Environment: %s\n", envName)
+	fmt.Println("Lock: HELD")
+	printLockHolder(info.Holder)
+	fmt.Println()
+
+	if !AutoApprove() {
+		env, err := cfg.Env(envName)
+		if err != nil {
+			return err
+		}
+
+		if env.RequireConfirmation {
+			confirmed, err := ui.ConfirmProduction(envName)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+
+		details := fmt.Sprintf("Terminating session %d to break the migration lock\n"+
+			"A migration running in that session is interrupted and may leave the database dirty",
+			info.Holder.ID)
+		confirmed, err := ui.ConfirmDangerous("release lock", details)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ui.Warning("Cancelled")
+			return nil
+		}
+	}
+
+	if err := migrator.ReleaseLock(cfg, envName, info.Holder.ID); err != nil {
+		return fmt.Errorf("release lock: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Lock released (session %d terminated)", info.Holder.ID))
+	ui.Info("Run 'janus status' to check for a dirty state")
+	return nil
+}
+
+// printLockHolder prints the fields the database reported for a lock holder
+func printLockHolder(h *migrator.LockHolder) {
+	fmt.Printf("  Session: %d\n", h.ID)
+	if h.User != "" {
+		fmt.Printf("  User: %s\n", h.User)
+	}
+	if h.Host != "" {
+		fmt.Printf("  Host: %s\n", h.Host)
+	}
+	if h.Application != "" {
+		fmt.Printf("  Application: %s\n", h.Application)
+	}
+	if h.Since != nil {
+		fmt.Printf("  Session started: %s (%s ago)\n", h.Since.Format(time.RFC3339), time.Since(*h.Since).Round(time.Second))
+	}
+	if h.Query != "" {
+		fmt.Printf("  Last query: %s\n", truncate(h.Query, 80))
+	}
+}
+
+// truncate shortens s to max runes on a single line
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
+	}
+	return s
+}
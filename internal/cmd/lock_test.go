@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
+)
+
+func TestLockCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "lock" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("lock command not registered")
+	}
+}
+
+func TestLockCmd_Subcommands(t *testing.T) {
+	want := map[string]bool{"status": false, "release": false}
+	for _, c := range lockCmd.Commands() {
+		if _, ok := want[c.Use]; ok {
+			want[c.Use] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("lock %s subcommand not registered", name)
+		}
+	}
+}
+
+func TestRunLockStatus_NoConfig(t *testing.T) {
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "dev"
+
+	withoutConfig(t)
+
+	if err := runLockStatus(nil, nil); err == nil {
+		t.Error("expected error without config")
+	}
+}
+
+func TestRunLockStatus_JSONOutput(t *testing.T) {
+	oldEnvName, oldOutput := envName, outputFormat
+	defer func() { envName, outputFormat = oldEnvName, oldOutput }()
+	envName = "dev"
+	outputFormat = outputJSON
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "sqlite3://" + filepath.Join(t.TempDir(), "dev.db"),
+				"migrations_path": t.TempDir(),
+			},
+		},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runLockStatus(nil, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runLockStatus() error: %v", err)
+	}
+
+	var info migrator.LockInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if info.Environment != "dev" || info.Supported {
+		t.Errorf("unexpected lock info: %+v", info)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("SELECT  1\n FROM t", 80); got != "SELECT 1 FROM t" {
+		t.Errorf("truncate() = %q", got)
+	}
+	if got := truncate("abcdefghij", 6); got != "abc..." {
+		t.Errorf("truncate() = %q, want abc...", got)
+	}
+}
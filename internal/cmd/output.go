@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Values accepted by --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var outputFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText,
+		"output format for status, history, validate, config show, lock status, test, diff and lint: text, json or yaml")
+}
+
+// structuredOutput reports whether --output selects a machine-readable format
+func structuredOutput() (bool, error) {
+	switch outputFormat {
+	case outputText, "":
+		return false, nil
+	case outputJSON, outputYAML:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid --output %q (expected text, json or yaml)", outputFormat)
+}
+
+// writeStructured writes v to stdout in the --output format
+func writeStructured(v interface{}) error {
+	return encodeStructured(os.Stdout, outputFormat, v)
+}
+
+func encodeStructured(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
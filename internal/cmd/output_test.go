@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
+)
+
+func TestOutputFlag(t *testing.T) {
+	flag := rootCmd.PersistentFlags().Lookup("output")
+	if flag == nil {
+		t.Fatal("output flag not found")
+	}
+	if flag.DefValue != outputText {
+		t.Errorf("output default = %s, want %s", flag.DefValue, outputText)
+	}
+}
+
+func TestStructuredOutput(t *testing.T) {
+	old := outputFormat
+	defer func() { outputFormat = old }()
+
+	tests := []struct {
+		format     string
+		structured bool
+		wantErr    bool
+	}{
+		{"text", false, false},
+		{"json", true, false},
+		{"yaml", true, false},
+		{"xml", false, true},
+	}
+
+	for _, tt := range tests {
+		outputFormat = tt.format
+		structured, err := structuredOutput()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+		if structured != tt.structured {
+			t.Errorf("%s: structured = %v, want %v", tt.format, structured, tt.structured)
+		}
+	}
+}
+
+func TestEncodeStructured_StatusSchema(t *testing.T) {
+	out := statusOutput{
+		Environment: "prod",
+		Status:      &migrator.Status{Version: 3, Pending: 2, Applied: 3, Total: 5},
+	}
+
+	var buf bytes.Buffer
+	if err := encodeStructured(&buf, outputJSON, out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"environment": "prod"`, `"version": 3`, `"dirty": false`, `"pending": 2`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("json output missing %s:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := encodeStructured(&buf, outputYAML, out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"environment: prod", "version: 3", "total: 5"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("yaml output missing %s:\n%s", want, buf.String())
+		}
+	}
+}
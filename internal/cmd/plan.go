@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var (
+	planOut    string
+	planTarget uint
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Record the migrations that would run",
+	Long: `Build a migration plan from the current database version to a target version.
+
+The plan records the environment, current and target versions, and the ordered
+migrations with their checksums. Save it with -o and run it later with
+'janus apply <planfile>', which refuses to run if anything has changed.
+
+Examples:
+  janus plan --env=prod -o plan.json
+  janus plan --env=prod --target=5 -o plan.json`,
+	RunE: runPlan,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&planOut, "out", "o", "", "Write the plan to this file")
+	planCmd.Flags().UintVar(&planTarget, "target", 0, "Target version (default: latest)")
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	target := mg.LatestVersion()
+	if cmd != nil && cmd.Flags().Changed("target") {
+		target = planTarget
+	}
+
+	p, err := mg.Plan(target)
+	if err != nil {
+		return err
+	}
+
+	printPlan(p)
+
+	if planOut != "" {
+		if err := migrator.WritePlanFile(planOut, p); err != nil {
+			return err
+		}
+		fmt.Fprintf(ui.Output, "\nPlan saved to %s\n", planOut)
+		fmt.Fprintf(ui.Output, "Run it with: janus apply %s\n", planOut)
+	}
+
+	return nil
+}
+
+// printPlan displays a plan in human-readable form
+func printPlan(p *migrator.Plan) {
+	fmt.Fprintln(ui.Output, "Migration Plan")
+	fmt.Fprintf(ui.Output, "Environment: %s\n", p.Environment)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", p.CurrentVersion)
+	fmt.Fprintf(ui.Output, "Target version: %d\n", p.TargetVersion)
+
+	if p.Direction == migrator.DirectionNone {
+		fmt.Fprintln(ui.Output, "\nNo changes. Database is at the target version.")
+		return
+	}
+
+	fmt.Fprintf(ui.Output, "Direction: %s (%d migration(s))\n\n", directionLabel(p.Direction), len(p.Migrations))
+	for _, m := range p.Migrations {
+		fmt.Fprintf(ui.Output, "  %06d - %s  sha256:%s\n", m.Version, m.Name, shortChecksum(m.Checksum))
+	}
+}
+
+func directionLabel(direction string) string {
+	if direction == migrator.DirectionDown {
+		return "DOWN"
+	}
+	return "UP"
+}
+
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
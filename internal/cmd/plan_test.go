@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPlanCmd_Registered(t *testing.T) {
+	// Verify plan command is registered
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "plan" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("plan command not registered")
+	}
+}
+
+func TestPlanCmd_Flags(t *testing.T) {
+	flag := planCmd.Flags().Lookup("out")
+	if flag == nil {
+		t.Fatal("out flag not found")
+	}
+	if flag.Shorthand != "o" {
+		t.Errorf("out shorthand = %q, want o", flag.Shorthand)
+	}
+	if planCmd.Flags().Lookup("target") == nil {
+		t.Error("target flag not found")
+	}
+}
+
+func TestPlanCmd_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	envName = "test"
+
+	var buf bytes.Buffer
+	planCmd.SetOut(&buf)
+	planCmd.SetErr(&buf)
+
+	err := runPlan(planCmd, []string{})
+	if err == nil {
+		t.Error("expected error with no config")
+	}
+}
+
+func TestShortChecksum(t *testing.T) {
+	if got := shortChecksum("0123456789abcdef"); got != "0123456789ab" {
+		t.Errorf("shortChecksum() = %q, want 0123456789ab", got)
+	}
+	if got := shortChecksum("abc"); got != "abc" {
+		t.Errorf("shortChecksum() = %q, want abc", got)
+	}
+}
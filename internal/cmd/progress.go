@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+// Values accepted by --progress
+const (
+	progressAuto  = "auto"
+	progressPlain = "plain"
+	progressJSON  = "json"
+)
+
+var progressFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&progressFormat, "progress", progressAuto,
+		"migration progress output: auto (live on TTY), plain, or json (NDJSON events)")
+}
+
+// attachProgress subscribes the renderer selected by --progress to mg's
+// events. With json, stdout carries only the events: human-readable output
+// moves to stderr.
+func attachProgress(mg *migrator.Migrator) error {
+	switch progressFormat {
+	case progressJSON:
+		enc := json.NewEncoder(os.Stdout)
+		ui.Output = os.Stderr
+		mg.OnEvent(func(e migrator.Event) {
+			_ = enc.Encode(e)
+		})
+	case progressAuto, progressPlain, "":
+		list := ui.NewProgressList(ui.Output, progressFormat != progressPlain && ui.IsTTY())
+		mg.OnEvent(func(e migrator.Event) {
+			label := fmt.Sprintf("%06d %s (%s)", e.Version, e.Name, e.Direction)
+			if e.Schema != "" {
+				label = e.Schema + ": " + label
+			}
+			switch e.Type {
+			case migrator.EventStart:
+				list.Start(label)
+			case migrator.EventFinish:
+				list.Finish(label, e.Duration)
+			case migrator.EventFail:
+				var err error
+				if e.Error != "" {
+					err = errors.New(e.Error)
+				}
+				list.Fail(label, e.Duration, err)
+			}
+		})
+	default:
+		return fmt.Errorf("invalid --progress %q (expected auto, plain or json)", progressFormat)
+	}
+	return nil
+}
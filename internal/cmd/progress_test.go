@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+func TestProgressFlag(t *testing.T) {
+	flag := rootCmd.PersistentFlags().Lookup("progress")
+	if flag == nil {
+		t.Fatal("progress flag not found")
+	}
+	if flag.DefValue != progressAuto {
+		t.Errorf("progress default = %s, want %s", flag.DefValue, progressAuto)
+	}
+}
+
+func TestAttachProgress_InvalidFormat(t *testing.T) {
+	old := progressFormat
+	defer func() { progressFormat = old }()
+	progressFormat = "xml"
+
+	if err := attachProgress(nil); err == nil {
+		t.Error("expected error for invalid --progress value")
+	}
+}
+
+func TestRunUp_ProgressJSONKeepsStdoutClean(t *testing.T) {
+	oldEnvName, oldAuto, oldProgress, oldOutput := envName, autoApprove, progressFormat, ui.Output
+	defer func() { envName, autoApprove, progressFormat, ui.Output = oldEnvName, oldAuto, oldProgress, oldOutput }()
+	envName, autoApprove, progressFormat = "dev", true, progressJSON
+	useMigrations(t, usersMigration, nil)
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	r, w, _ := os.Pipe()
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	os.Stdout, os.Stderr = w, devNull
+
+	err := runUp(upCmd, nil)
+
+	_ = w.Close()
+	_ = devNull.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	if err != nil {
+		t.Fatalf("runUp() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("stdout = %q, want a start and a finish event only", out)
+	}
+	for _, line := range lines {
+		var e migrator.Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Errorf("stdout line %q is not an event: %v", line, err)
+		}
+	}
+}
+
+func TestRunUp_ProgressShowsError(t *testing.T) {
+	oldEnvName, oldAuto, oldProgress, oldOutput := envName, autoApprove, progressFormat, ui.Output
+	defer func() { envName, autoApprove, progressFormat, ui.Output = oldEnvName, oldAuto, oldProgress, oldOutput }()
+	envName, autoApprove, progressFormat = "dev", true, progressPlain
+	var buf bytes.Buffer
+	ui.Output = &buf
+	path := useMigrations(t, map[string]string{
+		"000001_broken.sql": "-- +migrate UP\nCREATE TABLE (;\n-- +migrate DOWN\nSELECT 1;",
+	}, nil)
+
+	if err := runUp(upCmd, nil); err == nil {
+		t.Fatal("runUp() should fail")
+	}
+	if !strings.Contains(buf.String(), "FAIL") || !strings.Contains(buf.String(), "syntax error") {
+		t.Errorf("progress output should show the error:\n%s", buf.String())
+	}
+
+	// The failure record goes next to the temporary config, not into the
+	// package directory
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), ".janus", "failures", "dev.json")); err != nil {
+		t.Errorf("failure record not written next to the config: %v", err)
+	}
+	if _, err := os.Stat(".janus"); !os.IsNotExist(err) {
+		t.Errorf("test left .janus in the package directory: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var redoSteps int
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back and re-apply the last migrations",
+	Long: `Roll back the last N applied migrations and apply them again,
+as one operation with a single confirmation.
+
+Migrations run one at a time; the first failure stops the redo and
+reports which migration and direction it stopped at.
+
+Examples:
+  janus redo                # Redo the last migration
+  janus redo --steps=3      # Redo the last 3 migrations`,
+	RunE: runRedo,
+}
+
+func init() {
+	redoCmd.Flags().IntVar(&redoSteps, "steps", 1, "Number of migrations to redo")
+	rootCmd.AddCommand(redoCmd)
+}
+
+func runRedo(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	if status.Applied == 0 {
+		ui.Info("No migrations to redo")
+		return nil
+	}
+
+	list, err := mg.RedoMigrations(redoSteps)
+	if err != nil {
+		return err
+	}
+
+	// Show what will happen
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", status.Version)
+	fmt.Fprintf(ui.Output, "Will roll back and re-apply %d migration(s):\n", len(list))
+	for _, m := range list {
+		fmt.Fprintf(ui.Output, "  %06d - %s\n", m.Version, m.Name)
+	}
+	fmt.Fprintln(ui.Output)
+
+	// Confirmation logic
+	if !AutoApprove() {
+		details := fmt.Sprintf("Rolling back and re-applying %d migration(s) in %s", len(list), envName)
+
+		if mg.RequiresConfirmation() {
+			confirmed, err := ui.ConfirmProduction(envName)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		} else {
+			confirmed, err := ui.ConfirmDangerous("redo", details)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+	}
+
+	if err := runInterruptible(mg, func() error { return mg.Redo(redoSteps) }); err != nil {
+		if isInterrupted(err) {
+			return err
+		}
+		var redoErr *migrator.RedoError
+		if errors.As(err, &redoErr) {
+			if current, statusErr := mg.Status(); statusErr == nil {
+				ui.Error(fmt.Sprintf("Redo stopped at %s of %06d - %s (version now %d, dirty: %v)",
+					directionLabel(redoErr.Direction), redoErr.Version, redoErr.Name, current.Version, current.Dirty))
+			}
+		}
+		return fmt.Errorf("redo failed: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Redid %d migration(s)", len(list)))
+	fmt.Fprintf(ui.Output, "Current version: %d\n", status.Version)
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedoCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "redo" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("redo command not registered")
+	}
+}
+
+func TestRedoCmd_Flags(t *testing.T) {
+	flag := redoCmd.Flags().Lookup("steps")
+	if flag == nil {
+		t.Fatal("steps flag not found")
+	}
+	if flag.DefValue != "1" {
+		t.Errorf("steps default = %s, want 1", flag.DefValue)
+	}
+}
+
+func TestRedoCmd_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	envName = "test"
+
+	var buf bytes.Buffer
+	redoCmd.SetOut(&buf)
+	redoCmd.SetErr(&buf)
+
+	if err := runRedo(redoCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
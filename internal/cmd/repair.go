@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var repairAction string
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Guided recovery from a dirty state",
+	Long: `Inspect a dirty database and recover from the failed migration.
+
+Shows the dirty version, the failed migration's SQL and, when this machine
+recorded it, the error the migration failed with. Then offers:
+
+  rerun-up       mark the previous version clean and run the UP again
+  run-down       mark the failed version clean and run its DOWN
+  mark-previous  mark the previous version clean (no SQL is run)
+  mark-current   mark the failed version clean (no SQL is run)
+
+Examples:
+  janus repair --env=dev                      # Interactive
+  janus repair --env=prod --action=rerun-up   # Choose the action up front
+  janus repair --env=ci --action=mark-previous --auto-approve`,
+	RunE: runRepair,
+}
+
+func init() {
+	repairCmd.Flags().StringVar(&repairAction, "action", "", "repair action: rerun-up, run-down, mark-previous or mark-current")
+	rootCmd.AddCommand(repairCmd)
+}
+
+func runRepair(cmd *cobra.Command, args []string) error {
+	var action migrator.RepairAction
+	if repairAction != "" {
+		action = migrator.RepairAction(repairAction)
+		if !isRepairAction(action) {
+			return fmt.Errorf("invalid --action %q (expected %s)", repairAction, repairActionList())
+		}
+	} else if AutoApprove() {
+		return fmt.Errorf("--action is required with --auto-approve")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	ds, err := mg.DirtyState()
+	if errors.Is(err, migrator.ErrNotDirty) {
+		ui.Success("Database is not dirty: nothing to repair")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	printDirtyState(ds)
+
+	if action == "" {
+		items := make([]string, len(migrator.RepairActions))
+		for i, a := range migrator.RepairActions {
+			items[i] = fmt.Sprintf("%-14s %s", a, repairSummary(ds, a))
+		}
+		index, err := ui.Select("Choose a repair action", items)
+		if err != nil {
+			return err
+		}
+		action = migrator.RepairActions[index]
+	}
+
+	fmt.Printf("\nAction: %s\n", action)
+	fmt.Println(repairExplanation(ds, action))
+	if action == migrator.RepairRunDown && strings.TrimSpace(ds.DownSQL) == "" {
+		ui.Warning("The DOWN section is empty: only the version will change")
+	}
+
+	// Confirmation logic
+	if !AutoApprove() {
+		details := fmt.Sprintf("Repairing dirty version %d: %s\n%s", ds.Version, action, repairSummary(ds, action))
+
+		if mg.RequiresConfirmation() {
+			confirmed, err := ui.ConfirmProduction(envName)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		} else {
+			confirmed, err := ui.ConfirmDangerous("repair", details)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+	}
+
+	if err := mg.Repair(ds, action); err != nil {
+		return fmt.Errorf("repair failed: %w", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	ui.Success(fmt.Sprintf("Repaired: version %s, dirty: %v", versionLabel(int(status.Version)), status.Dirty))
+	return nil
+}
+
+// printDirtyState shows what is known about the failed migration
+func printDirtyState(ds *migrator.DirtyState) {
+	fmt.Printf("Environment: %s\n", envName)
+	fmt.Printf("Dirty version: %d\n", ds.Version)
+	fmt.Printf("Failed migration: %06d - %s\n", ds.Migration, ds.Name)
+
+	sql := ds.UpSQL
+	switch ds.Direction {
+	case migrator.DirectionUp:
+		fmt.Println("Failed while: applying UP")
+	case migrator.DirectionDown:
+		fmt.Println("Failed while: rolling back (DOWN)")
+		sql = ds.DownSQL
+	default:
+		fmt.Println("Failed while: unknown (no failure recorded here; assuming UP)")
+	}
+
+	if ds.Failure != nil {
+		fmt.Printf("Recorded error (%s):\n", ds.Failure.Time.Format("2006-01-02 15:04:05 MST"))
+		printIndented(ds.Failure.Error)
+	}
+
+	fmt.Println("\nSQL:")
+	printIndented(sql)
+}
+
+// printIndented prints multi-line text indented by two spaces
+func printIndented(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// repairSummary describes what an action does, in one line
+func repairSummary(ds *migrator.DirtyState, a migrator.RepairAction) string {
+	prev := versionLabel(ds.PreviousVersion)
+	switch a {
+	case migrator.RepairRerunUp:
+		return fmt.Sprintf("mark version %s clean, then run UP of %d", prev, ds.Migration)
+	case migrator.RepairRunDown:
+		return fmt.Sprintf("mark version %d clean, then run DOWN of %d (ends at %s)", ds.Migration, ds.Migration, prev)
+	case migrator.RepairMarkPrevious:
+		return fmt.Sprintf("mark version %s clean, run no SQL", prev)
+	case migrator.RepairMarkCurrent:
+		return fmt.Sprintf("mark version %d clean, run no SQL", ds.Migration)
+	}
+	return ""
+}
+
+// repairExplanation says when an action is the right choice
+func repairExplanation(ds *migrator.DirtyState, a migrator.RepairAction) string {
+	switch a {
+	case migrator.RepairRerunUp:
+		return "Use when the failed migration left no changes behind (e.g. PostgreSQL rolled\n" +
+			"back its transaction) and you have fixed the cause. The UP runs again from the start."
+	case migrator.RepairRunDown:
+		return "Use when the UP was partly applied and its DOWN can undo the partial changes\n" +
+			"(e.g. it uses IF EXISTS). Afterwards 'janus up' applies the migration again."
+	case migrator.RepairMarkPrevious:
+		return fmt.Sprintf("Use when none of migration %d's changes are in the database.\n"+
+			"The next 'janus up' applies it again.", ds.Migration)
+	case migrator.RepairMarkCurrent:
+		return fmt.Sprintf("Use when migration %d's changes are fully in the database, e.g. you\n"+
+			"finished the remaining statements by hand.", ds.Migration)
+	}
+	return ""
+}
+
+// versionLabel renders a version, with "none" for NilVersion
+func versionLabel(v int) string {
+	if v < 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+func isRepairAction(a migrator.RepairAction) bool {
+	for _, known := range migrator.RepairActions {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+func repairActionList() string {
+	names := make([]string, len(migrator.RepairActions))
+	for i, a := range migrator.RepairActions {
+		names[i] = string(a)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
+)
+
+func TestRepairCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "repair" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("repair command not registered")
+	}
+}
+
+func TestRepairCmd_Flags(t *testing.T) {
+	if repairCmd.Flags().Lookup("action") == nil {
+		t.Error("repair command should have --action flag")
+	}
+}
+
+func TestRunRepair_InvalidAction(t *testing.T) {
+	oldAction := repairAction
+	defer func() { repairAction = oldAction }()
+	repairAction = "rewind"
+
+	if err := runRepair(nil, nil); err == nil {
+		t.Error("expected error for invalid action")
+	}
+}
+
+func TestRunRepair_AutoApproveRequiresAction(t *testing.T) {
+	oldAction, oldAuto := repairAction, autoApprove
+	defer func() { repairAction, autoApprove = oldAction, oldAuto }()
+	repairAction = ""
+	autoApprove = true
+
+	if err := runRepair(nil, nil); err == nil {
+		t.Error("expected error when --auto-approve is given without --action")
+	}
+}
+
+func TestRunRepair_MarkPrevious(t *testing.T) {
+	oldEnvName, oldAction, oldAuto, oldDir := envName, repairAction, autoApprove, migrator.FailureDir
+	defer func() {
+		envName, repairAction, autoApprove, migrator.FailureDir = oldEnvName, oldAction, oldAuto, oldDir
+	}()
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_a.sql"), []byte("-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;"), 0644)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000002_b.sql"), []byte("-- +migrate UP\nNOT VALID SQL;\n-- +migrate DOWN\nSELECT 1;"), 0644)
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "sqlite3://" + filepath.Join(dir, "dev.db"),
+				"migrations_path": migrationsDir,
+			},
+		},
+	})
+	migrator.FailureDir = filepath.Join(dir, "failures")
+	envName = "dev"
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg, err := migrator.New(cfg, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Up(0); err == nil {
+		t.Fatal("expected migration 2 to fail")
+	}
+	_ = mg.Close()
+
+	repairAction = string(migrator.RepairMarkPrevious)
+	autoApprove = true
+	if err := runRepair(nil, nil); err != nil {
+		t.Fatalf("runRepair() error: %v", err)
+	}
+
+	mg, err = migrator.New(cfg, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mg.Close() }()
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 || status.Dirty {
+		t.Errorf("status = %+v, want clean at 1", status)
+	}
+}
+
+func TestVersionLabel(t *testing.T) {
+	if got := versionLabel(-1); got != "none" {
+		t.Errorf("versionLabel(-1) = %q, want none", got)
+	}
+	if got := versionLabel(4); got != "4" {
+		t.Errorf("versionLabel(4) = %q, want 4", got)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Roll back every applied migration",
+	Long: `Roll back every applied migration by running their DOWN sections.
+
+Disabled for environments with require_confirmation: true unless they
+also set allow_reset: true.
+
+Examples:
+  janus reset --env=dev
+  janus reset --env=review --auto-approve`,
+	RunE: runReset,
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	mg, err := openMigrator(cfg, envName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = mg.Close() }()
+
+	if !mg.ResetAllowed() {
+		return migrator.ErrResetNotAllowed
+	}
+
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	if status.Applied == 0 {
+		ui.Info("No migrations to rollback")
+		return nil
+	}
+
+	// Show what will happen
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", status.Version)
+	fmt.Fprintf(ui.Output, "Will rollback: all %d applied migration(s)\n", status.Applied)
+	fmt.Fprintln(ui.Output)
+
+	// Confirmation logic
+	if !AutoApprove() {
+		details := fmt.Sprintf("Rolling back all %d applied migration(s) in %s", status.Applied, envName)
+
+		if mg.RequiresConfirmation() {
+			confirmed, err := ui.ConfirmProduction(envName)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		} else {
+			confirmed, err := ui.ConfirmDangerous("reset", details)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.Warning("Cancelled")
+				return nil
+			}
+		}
+	}
+
+	if err := runInterruptible(mg, mg.Reset); err != nil {
+		if err == migrate.ErrNoChange {
+			ui.Info("No migrations to rollback")
+			return nil
+		}
+		if isInterrupted(err) {
+			return err
+		}
+		return fmt.Errorf("reset failed: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Rolled back %d migration(s)", status.Applied))
+	fmt.Fprintln(ui.Output, "Current version: none (clean slate)")
+	return nil
+}
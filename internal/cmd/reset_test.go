@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/migrator"
+)
+
+func TestResetCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "reset" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("reset command not registered")
+	}
+}
+
+func TestResetCmd_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	envName = "test"
+
+	var buf bytes.Buffer
+	resetCmd.SetOut(&buf)
+	resetCmd.SetErr(&buf)
+
+	if err := runReset(resetCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
+
+func TestResetCmd_RequireConfirmationBlocks(t *testing.T) {
+	oldEnvName, oldAuto := envName, autoApprove
+	defer func() {
+		envName, autoApprove = oldEnvName, oldAuto
+	}()
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"prod": map[string]interface{}{
+				"database_url":         "sqlite3://" + filepath.Join(t.TempDir(), "prod.db"),
+				"migrations_path":      t.TempDir(),
+				"require_confirmation": true,
+			},
+		},
+	})
+	envName = "prod"
+	autoApprove = true
+
+	err := runReset(resetCmd, []string{})
+	if !errors.Is(err, migrator.ErrResetNotAllowed) {
+		t.Errorf("expected ErrResetNotAllowed, got: %v", err)
+	}
+}
@@ -1,19 +1,21 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/migrator"
 )
 
 var (
 	cfgFile               string
 	envName               string
+	schemaName            string
 	autoApprove           bool
 	version, commit, date string
-	configLoaded          bool
 )
 
 var rootCmd = &cobra.Command{
@@ -38,10 +40,10 @@ func GetEnvName() string {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ./janus.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: $JANUS_CONFIG, else janus.yaml in this or a parent directory)")
 	rootCmd.PersistentFlags().StringVar(&envName, "env", "dev", "environment name")
 	rootCmd.PersistentFlags().BoolVar(&autoApprove, "auto-approve", false, "skip confirmation prompts (for CI/CD)")
+	rootCmd.PersistentFlags().StringVar(&schemaName, "schema", "", "limit a schema-per-tenant environment to one schema")
 }
 
 // AutoApprove returns whether confirmation prompts should be skipped
@@ -49,28 +51,50 @@ func AutoApprove() bool {
 	return autoApprove
 }
 
-func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-	} else {
-		viper.SetConfigName("janus")
-		viper.SetConfigType("yaml")
-		viper.AddConfigPath(".")
+// loadConfig reads the file given with --config or $JANUS_CONFIG, else the
+// janus.yaml in the working directory or its closest parent, with its
+// local and --env layers merged on top. Relative paths in a janus.yaml
+// found in a parent directory are resolved against that directory.
+func loadConfig() (*config.Config, error) {
+	path := cfgFile
+	if path == "" {
+		path = os.Getenv(config.EnvVar)
 	}
-	viper.AutomaticEnv()
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
-			os.Exit(1)
+	opts := config.LoadOptions{Env: envName}
+	if path == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return nil, err
 		}
-		// Config file not found is OK for some commands (version, help)
-	} else {
-		configLoaded = true
+		path = found
+	}
+	// Relative paths in the config are relative to its file, however the
+	// file was chosen
+	if dir := filepath.Dir(path); dir != "." {
+		opts.Dir = dir
+	}
+	return config.LoadLayered(path, opts)
+}
+
+// openMigratorToApply is openMigrator for commands that apply migrations:
+// the schemas a schema-per-tenant environment lists are created first if
+// missing
+func openMigratorToApply(cfg *config.Config, env string) (*migrator.Migrator, error) {
+	var only []string
+	if schemaName != "" {
+		only = []string{schemaName}
 	}
+	if err := migrator.CreateSchemas(cfg, env, only...); err != nil {
+		return nil, err
+	}
+	return openMigrator(cfg, env)
 }
 
-// IsConfigLoaded returns whether a config file was found and loaded
-func IsConfigLoaded() bool {
-	return configLoaded
+// openMigrator creates the migrator for an environment, limited to the
+// schema given with --schema
+func openMigrator(cfg *config.Config, env string) (*migrator.Migrator, error) {
+	if schemaName != "" {
+		return migrator.NewForSchema(cfg, env, schemaName)
+	}
+	return migrator.New(cfg, env)
 }
@@ -1,9 +1,99 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/migrator"
 )
 
+// useConfig writes settings to a janus.yaml and selects it with --config
+// for the rest of the test
+func useConfig(t *testing.T, settings map[string]interface{}) string {
+	t.Helper()
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "janus.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldCfgFile := cfgFile
+	cfgFile = path
+	t.Cleanup(func() { cfgFile = oldCfgFile })
+	return path
+}
+
+// usersMigration is a single migration creating a users table
+var usersMigration = map[string]string{
+	"000001_users.sql": "-- +migrate UP\nCREATE TABLE users (id INTEGER);\n-- +migrate DOWN\nDROP TABLE users;",
+}
+
+// useMigrations is useConfig for a project holding the given migration
+// files: they are written to a migrations directory next to janus.yaml,
+// which defaults migrations_path to it. Settings without environments get
+// a "dev" environment. Relative database paths, as in sqlite3://dev.db,
+// resolve next to janus.yaml too.
+func useMigrations(t *testing.T, files map[string]string, settings map[string]interface{}) string {
+	t.Helper()
+
+	merged := map[string]interface{}{
+		"defaults":     map[string]interface{}{"migrations_path": "migrations"},
+		"environments": map[string]interface{}{"dev": map[string]interface{}{"database_url": "sqlite3://dev.db"}},
+	}
+	for key, value := range settings {
+		merged[key] = value
+	}
+	path := useConfig(t, merged)
+
+	dir := filepath.Join(filepath.Dir(path), "migrations")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// migrateUp applies every migration to an environment of the selected
+// config
+func migrateUp(t *testing.T, env string) {
+	t.Helper()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg, err := migrator.New(cfg, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mg.Close() }()
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withoutConfig points --config at a missing file for the rest of the test
+func withoutConfig(t *testing.T) {
+	t.Helper()
+
+	oldCfgFile := cfgFile
+	cfgFile = filepath.Join(t.TempDir(), "missing.yaml")
+	t.Cleanup(func() { cfgFile = oldCfgFile })
+}
+
 func TestSetVersionInfo(t *testing.T) {
 	SetVersionInfo("1.0.0", "abc123", "2026-01-01")
 
@@ -34,3 +124,72 @@ func TestRootCmdExists(t *testing.T) {
 		t.Errorf("expected Use 'janus', got '%s'", rootCmd.Use)
 	}
 }
+
+func TestOpenMigrator_Schema(t *testing.T) {
+	oldSchema := schemaName
+	defer func() { schemaName = oldSchema }()
+	useMigrations(t, usersMigration, nil)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaName = "tenant_a"
+	if _, err := openMigrator(cfg, "dev"); err == nil || !strings.Contains(err.Error(), "does not configure schemas") {
+		t.Errorf("expected --schema to require a schema environment, got: %v", err)
+	}
+
+	schemaName = ""
+	mg, err := openMigrator(cfg, "dev")
+	if err != nil {
+		t.Fatalf("openMigrator() error: %v", err)
+	}
+	_ = mg.Close()
+}
+
+func TestLoadConfig_Lookup(t *testing.T) {
+	oldCfgFile, oldEnvName := cfgFile, envName
+	defer func() { cfgFile, envName = oldCfgFile, oldEnvName }()
+	cfgFile, envName = "", "dev"
+
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "janus.yaml"),
+		[]byte("environments:\n  dev:\n    database_url: sqlite3://dev.db\n"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "janus.local.yaml"),
+		[]byte("environments:\n  dev:\n    schema_file: schema.sql\n"), 0644)
+	sub := filepath.Join(root, "services", "api")
+	_ = os.MkdirAll(sub, 0755)
+	t.Chdir(sub)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() from a subdirectory: %v", err)
+	}
+	dev := cfg.Environments["dev"]
+	if dev.DatabaseURL != "sqlite3://"+filepath.Join(root, "dev.db") || dev.SchemaFile != filepath.Join(root, "schema.sql") {
+		t.Errorf("paths not resolved against %s: %+v", root, dev)
+	}
+	if len(cfg.Layers) != 1 {
+		t.Errorf("janus.local.yaml not merged: %v", cfg.Layers)
+	}
+
+	// Paths resolve against the file's directory, not the working one
+	otherDir := t.TempDir()
+	other := filepath.Join(otherDir, "other.yaml")
+	_ = os.WriteFile(other, []byte("environments:\n  ci:\n    database_url: sqlite3://ci.db\n    migrations_path: ./migrations\n"), 0644)
+	t.Setenv(config.EnvVar, other)
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() with %s: %v", config.EnvVar, err)
+	}
+	ci := cfg.Environments["ci"]
+	if cfg.File != other || ci.DatabaseURL != "sqlite3://"+filepath.Join(otherDir, "ci.db") ||
+		ci.MigrationsPath != filepath.Join(otherDir, "migrations") {
+		t.Errorf("%s not honoured from a subdirectory: %s %+v", config.EnvVar, cfg.File, ci)
+	}
+
+	cfgFile = filepath.Join(root, "janus.yaml")
+	if cfg, err = loadConfig(); err != nil || cfg.File != cfgFile {
+		t.Errorf("--config should take precedence over %s: %v", config.EnvVar, err)
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+// Process exit codes
+const (
+	ExitCodeError = 1
+	// ExitCodeInterrupted means a signal stopped the run between migrations
+	ExitCodeInterrupted = 130
+	// ExitCodeAborted means a second signal killed the run mid-migration
+	ExitCodeAborted = 131
+)
+
+// exitCoder is implemented by errors that carry a specific exit code
+type exitCoder interface {
+	ExitCode() int
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+	return ExitCodeError
+}
+
+// interruptedError reports a run that was stopped by SIGINT/SIGTERM
+type interruptedError struct {
+	completed int
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("interrupted after %d migration(s)", e.completed)
+}
+
+func (e *interruptedError) ExitCode() int {
+	return ExitCodeInterrupted
+}
+
+// runInterruptible runs fn while translating SIGINT/SIGTERM into a graceful
+// stop of mg: the running migration finishes (or rolls back) and no new one
+// starts. A second signal aborts the process immediately.
+// When the run was stopped, the migrations that completed are reported and an
+// error carrying ExitCodeInterrupted is returned.
+func runInterruptible(mg *migrator.Migrator, fn func() error) error {
+	before, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+
+	sigs := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(sigs)
+		close(done)
+	}()
+
+	go func() {
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(ui.Output)
+		ui.Warning("Interrupt received: stopping after the current migration (press Ctrl-C again to abort)")
+		mg.GracefulStop()
+
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		ui.Error("Aborted: the database may be left in a dirty state (check 'janus status')")
+		os.Exit(ExitCodeAborted)
+	}()
+
+	runErr := fn()
+	if !mg.Stopped() {
+		return runErr
+	}
+
+	after, err := mg.Status()
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("get status: %w", err))
+	}
+	completed := reportCompleted(mg, before.Version, after.Version)
+	if runErr != nil {
+		return runErr
+	}
+	return &interruptedError{completed: completed}
+}
+
+// reportCompleted prints the migrations applied or rolled back between two
+// versions and returns how many there were
+func reportCompleted(mg *migrator.Migrator, from, to uint) int {
+	var list []migrator.MigrationInfo
+	verb := "Applied"
+	if to < from {
+		verb = "Rolled back"
+		list = mg.MigrationsBetween(to, from)
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	} else {
+		list = mg.MigrationsBetween(from, to)
+	}
+
+	ui.Warning(fmt.Sprintf("Stopped by signal. %s %d migration(s) before stopping:", verb, len(list)))
+	for _, m := range list {
+		fmt.Fprintf(ui.Output, "  %06d - %s\n", m.Version, m.Name)
+	}
+	fmt.Fprintf(ui.Output, "Current version: %d\n", to)
+	return len(list)
+}
+
+// isInterrupted reports whether err came from a signal-stopped run
+func isInterrupted(err error) bool {
+	var ie *interruptedError
+	return errors.As(err, &ie)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"plain error", errors.New("boom"), ExitCodeError},
+		{"interrupted", &interruptedError{completed: 2}, ExitCodeInterrupted},
+		{"wrapped interrupted", fmt.Errorf("up: %w", &interruptedError{}), ExitCodeInterrupted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsInterrupted(t *testing.T) {
+	if !isInterrupted(&interruptedError{}) {
+		t.Error("isInterrupted should detect interruptedError")
+	}
+	if isInterrupted(errors.New("other")) {
+		t.Error("isInterrupted should ignore other errors")
+	}
+}
+
+func TestInterruptedError_Message(t *testing.T) {
+	err := &interruptedError{completed: 3}
+	if err.Error() != "interrupted after 3 migration(s)" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+// writeSchemaSnapshot refreshes the environment's schema_file after a
+// successful run. A failure is only a warning: the migrations did apply.
+func writeSchemaSnapshot(mg *migrator.Migrator) {
+	if mg.SchemaFile() == "" {
+		return
+	}
+	if err := mg.WriteSchemaSnapshot(); err != nil {
+		ui.Warning(fmt.Sprintf("Schema snapshot not written: %v", err))
+		return
+	}
+	fmt.Fprintf(ui.Output, "Schema snapshot: %s\n", mg.SchemaFile())
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunUp_WritesSchemaSnapshot(t *testing.T) {
+	oldEnvName, oldAuto := envName, autoApprove
+	defer func() { envName, autoApprove = oldEnvName, oldAuto }()
+	envName = "dev"
+	autoApprove = true
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_users.sql"),
+		[]byte("-- +migrate UP\nCREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);\n-- +migrate DOWN\nDROP TABLE users;"), 0644)
+	schemaFile := filepath.Join(dir, "schema.sql")
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "sqlite3://" + filepath.Join(dir, "dev.db"),
+				"migrations_path": migrationsDir,
+				"schema_file":     schemaFile,
+			},
+		},
+	})
+
+	if err := runUp(upCmd, nil); err != nil {
+		t.Fatalf("runUp() error: %v", err)
+	}
+
+	got, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("schema snapshot not written: %v", err)
+	}
+	if !strings.Contains(string(got), "migration version 1") || !strings.Contains(string(got), "email TEXT NOT NULL") {
+		t.Errorf("unexpected snapshot:\n%s", got)
+	}
+}
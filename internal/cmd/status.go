@@ -2,25 +2,67 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cesc1802/janus/internal/config"
 	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
-	Long:  `Display current migration version, dirty state, and pending count.`,
-	RunE:  runStatus,
+	Long: `Display current migration version, dirty state, and pending count.
+
+With --all-envs, every configured environment is queried concurrently and
+shown as one matrix. Environments at a lower version than the environment
+before them in promotion_order are flagged as behind.
+
+Examples:
+  janus status --env=prod
+  janus status --all-envs
+  janus status --all-envs --concurrency=8 --timeout=5s`,
+	RunE: runStatus,
 }
 
+var (
+	statusAllEnvs     bool
+	statusConcurrency int
+	statusTimeout     time.Duration
+)
+
 func init() {
+	statusCmd.Flags().BoolVar(&statusAllEnvs, "all-envs", false, "Show the status of every environment")
+	statusCmd.Flags().IntVar(&statusConcurrency, "concurrency", 4, "With --all-envs, number of environments queried at once")
+	statusCmd.Flags().DurationVar(&statusTimeout, "timeout", 10*time.Second, "With --all-envs, time each environment has to answer")
 	rootCmd.AddCommand(statusCmd)
 }
 
+// statusOutput is the --output schema for status
+type statusOutput struct {
+	Environment      string `json:"environment" yaml:"environment"`
+	*migrator.Status `yaml:",inline"`
+	// Schemas holds each schema's status for schema-per-tenant environments;
+	// the fields above then combine them
+	Schemas []migrator.SchemaStatus `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
 func runStatus(cmd *cobra.Command, args []string) error {
-	mg, err := migrator.New(envName)
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if statusAllEnvs {
+		return runStatusAll(cfg, structured)
+	}
+
+	mg, err := openMigrator(cfg, envName)
 	if err != nil {
 		return err
 	}
@@ -31,6 +73,21 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var schemas []migrator.SchemaStatus
+	if len(mg.Schemas()) > 0 {
+		if schemas, err = mg.SchemaStatuses(); err != nil {
+			return err
+		}
+	}
+
+	if structured {
+		return writeStructured(statusOutput{Environment: envName, Status: status, Schemas: schemas})
+	}
+	if schemas != nil {
+		printSchemaStatuses(schemas)
+		return nil
+	}
+
 	fmt.Printf("Environment: %s\n", envName)
 	if status.Version > 0 {
 		fmt.Printf("Current Version: %d\n", status.Version)
@@ -44,8 +101,103 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if status.Dirty {
 		fmt.Println("\nWARNING: Database is in dirty state.")
 		fmt.Println("This usually means a migration failed mid-execution.")
-		fmt.Printf("Fix with: janus force %d --env=%s\n", status.Version, envName)
+		fmt.Printf("Fix with: janus repair --env=%s\n", envName)
+		fmt.Printf("      or: janus force %d --env=%s\n", status.Version, envName)
+	}
+
+	return nil
+}
+
+// printSchemaStatuses shows one row per schema of a schema-per-tenant
+// environment
+func printSchemaStatuses(schemas []migrator.SchemaStatus) {
+	width := len("SCHEMA")
+	for _, s := range schemas {
+		width = max(width, len(s.Schema))
+	}
+
+	fmt.Printf("Environment: %s (%d schemas)\n\n", envName, len(schemas))
+	fmt.Printf("%-*s  %-8s  %-5s  %-9s  %s\n", width, "SCHEMA", "VERSION", "DIRTY", "APPLIED", "PENDING")
+	var dirty []string
+	for _, s := range schemas {
+		version := "none"
+		if s.Version > 0 {
+			version = fmt.Sprintf("%d", s.Version)
+		}
+		flag := "no"
+		if s.Dirty {
+			flag = ui.Colorize(ui.ColorRed, fmt.Sprintf("%-5s", "yes"))
+			dirty = append(dirty, s.Schema)
+		}
+		fmt.Printf("%-*s  %-8s  %-5s  %-9s  %d\n", width, s.Schema, version, flag,
+			fmt.Sprintf("%d / %d", s.Applied, s.Total), s.Pending)
+	}
+
+	for _, name := range dirty {
+		fmt.Printf("\nWARNING: Schema %s is in dirty state.\n", name)
+		fmt.Printf("Fix with: janus repair --env=%s --schema=%s\n", envName, name)
+	}
+}
+
+// statusAllOutput is the --output schema for status --all-envs
+type statusAllOutput struct {
+	Environments []migrator.EnvStatus `json:"environments" yaml:"environments"`
+}
+
+func runStatusAll(cfg *config.Config, structured bool) error {
+	results := migrator.StatusAll(cfg, statusConcurrency, statusTimeout)
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if structured {
+		if err := writeStructured(statusAllOutput{Environments: results}); err != nil {
+			return err
+		}
+	} else {
+		printStatusMatrix(results)
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("could not read status of %d environment(s)", failed)
+	}
 	return nil
 }
+
+func printStatusMatrix(results []migrator.EnvStatus) {
+	width := len("ENVIRONMENT")
+	for _, r := range results {
+		width = max(width, len(r.Environment))
+	}
+
+	fmt.Printf("%-*s  %-8s  %-5s  %-7s  %s\n", width, "ENVIRONMENT", "VERSION", "DIRTY", "PENDING", "NOTE")
+	for _, r := range results {
+		if r.Fleet {
+			fmt.Printf("%-*s  %-8s  %-5s  %-7s  %s\n", width, r.Environment, "-", "-", "-", "fleet: versions vary by target")
+			continue
+		}
+		if r.Status == nil {
+			fmt.Printf("%-*s  %-8s  %-5s  %-7s  %s\n", width, r.Environment, "-", "-", "-",
+				ui.Colorize(ui.ColorRed, "error: "+truncate(r.Error, 80)))
+			continue
+		}
+
+		version := "none"
+		if r.Status.Version > 0 {
+			version = fmt.Sprintf("%d", r.Status.Version)
+		}
+		dirty := "no"
+		if r.Status.Dirty {
+			dirty = ui.Colorize(ui.ColorRed, fmt.Sprintf("%-5s", "yes"))
+		}
+		var note string
+		if r.Behind != "" {
+			note = ui.Colorize(ui.ColorYellow, "behind "+r.Behind)
+		}
+		fmt.Printf("%-*s  %-8s  %-5s  %-7d  %s\n", width, r.Environment, version, dirty, r.Status.Pending, note)
+	}
+}
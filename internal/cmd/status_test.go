@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
 	"testing"
 
-	"github.com/spf13/viper"
-
-	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/migrator"
 )
 
 func TestStatusCmd_Registered(t *testing.T) {
@@ -24,8 +26,7 @@ func TestStatusCmd_Registered(t *testing.T) {
 }
 
 func TestStatusCmd_NoConfig(t *testing.T) {
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	envName = "test"
 
@@ -38,3 +39,102 @@ func TestStatusCmd_NoConfig(t *testing.T) {
 		t.Error("expected error with no config")
 	}
 }
+
+func TestStatusCmd_AllEnvsFlags(t *testing.T) {
+	for _, name := range []string{"all-envs", "concurrency", "timeout"} {
+		if statusCmd.Flags().Lookup(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+// runStatusAllCapture runs status --all-envs and returns stdout
+func runStatusAllCapture(t *testing.T) (string, error) {
+	t.Helper()
+
+	oldAllEnvs := statusAllEnvs
+	defer func() { statusAllEnvs = oldAllEnvs }()
+	statusAllEnvs = true
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runStatus(statusCmd, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+	return string(out), err
+}
+
+// statusEnvs are dev and prod in promotion order
+var statusEnvs = map[string]interface{}{
+	"environments": map[string]interface{}{
+		"dev":  map[string]interface{}{"database_url": "sqlite3://dev.db"},
+		"prod": map[string]interface{}{"database_url": "sqlite3://prod.db"},
+	},
+	"promotion_order": []string{"dev", "prod"},
+}
+
+func TestRunStatus_AllEnvsJSON(t *testing.T) {
+	oldOutput := outputFormat
+	defer func() { outputFormat = oldOutput }()
+	outputFormat = outputJSON
+	useMigrations(t, usersMigration, statusEnvs)
+	migrateUp(t, "dev")
+
+	out, err := runStatusAllCapture(t)
+	if err != nil {
+		t.Fatalf("runStatus() error: %v", err)
+	}
+
+	var result statusAllOutput
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if len(result.Environments) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	dev, prod := result.Environments[0], result.Environments[1]
+	if dev.Environment != "dev" || dev.Status == nil || dev.Status.Version != 1 {
+		t.Errorf("unexpected dev status: %+v", dev)
+	}
+	if prod.Environment != "prod" || prod.Status == nil || prod.Status.Pending != 1 || prod.Behind != "dev" {
+		t.Errorf("unexpected prod status: %+v", prod)
+	}
+}
+
+func TestRunStatus_AllEnvsMatrix(t *testing.T) {
+	useMigrations(t, usersMigration, statusEnvs)
+	migrateUp(t, "dev")
+
+	out, err := runStatusAllCapture(t)
+	if err != nil {
+		t.Fatalf("runStatus() error: %v", err)
+	}
+	if !strings.Contains(out, "ENVIRONMENT") || !strings.Contains(out, "behind dev") {
+		t.Errorf("unexpected matrix:\n%s", out)
+	}
+}
+
+func TestPrintSchemaStatuses(t *testing.T) {
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printSchemaStatuses([]migrator.SchemaStatus{
+		{Schema: "tenant_a", Status: &migrator.Status{Version: 3, Applied: 3, Total: 3}},
+		{Schema: "tenant_b", Status: &migrator.Status{Version: 2, Dirty: true, Applied: 2, Pending: 1, Total: 3}},
+	})
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	for _, want := range []string{"SCHEMA", "tenant_a  3         no     3 / 3      0", "--schema=tenant_b"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
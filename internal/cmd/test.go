@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cesc1802/janus/internal/migrator"
+	"github.com/cesc1802/janus/internal/ui"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Check that every migration's DOWN reverses its UP",
+	Long: `Run every migration UP, DOWN and UP again against a scratch database
+and compare the schema after each step.
+
+Reports each migration whose DOWN does not restore the previous schema,
+or whose UP produces a different schema the second time.
+
+The scratch database is the environment's shadow_database_url, or a
+temporary SQLite file when none is set. It is wiped before the check;
+never point shadow_database_url at a database you care about.
+
+Examples:
+  janus test --env=dev
+  janus test --env=dev --output=json`,
+	RunE: runTest,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+}
+
+// testOutput is the --output json/yaml shape of test
+type testOutput struct {
+	Environment string                     `json:"env" yaml:"env"`
+	Passed      bool                       `json:"passed" yaml:"passed"`
+	Error       string                     `json:"error,omitempty" yaml:"error,omitempty"`
+	Migrations  []migrator.RoundTripResult `json:"migrations" yaml:"migrations"`
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	var onResult func(migrator.RoundTripResult)
+	if !structured {
+		fmt.Printf("Environment: %s\n", envName)
+		fmt.Println("Checking UP -> DOWN -> UP on a scratch database...")
+		fmt.Println()
+		onResult = printRoundTripResult
+	}
+
+	results, runErr := migrator.RoundTrip(cfg, envName, onResult)
+
+	failed := 0
+	for _, r := range results {
+		if !r.OK() {
+			failed++
+		}
+	}
+
+	if structured {
+		out := testOutput{
+			Environment: envName,
+			Passed:      runErr == nil && failed == 0,
+			Migrations:  results,
+		}
+		if out.Migrations == nil {
+			out.Migrations = []migrator.RoundTripResult{}
+		}
+		if runErr != nil {
+			out.Error = runErr.Error()
+		}
+		if err := writeStructured(out); err != nil {
+			return err
+		}
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("round-trip check failed: %w", runErr)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d migration(s) do not round-trip", failed, len(results))
+	}
+	if !structured {
+		fmt.Println()
+		ui.Success(fmt.Sprintf("All %d migration(s) round-trip cleanly", len(results)))
+	}
+	return nil
+}
+
+func printRoundTripResult(r migrator.RoundTripResult) {
+	if r.OK() {
+		fmt.Printf("  OK    %06d - %s\n", r.Version, r.Name)
+		return
+	}
+	fmt.Printf("  FAIL  %06d - %s\n", r.Version, r.Name)
+	for _, p := range r.Problems {
+		fmt.Printf("          %s\n", p)
+	}
+	for _, c := range r.Changes {
+		fmt.Printf("            - %s\n", c)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTestCmd_Registered(t *testing.T) {
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Use == "test" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("test command not registered")
+	}
+}
+
+func TestRunTest_NoConfig(t *testing.T) {
+	withoutConfig(t)
+
+	oldEnvName := envName
+	defer func() { envName = oldEnvName }()
+	envName = "test"
+
+	if err := runTest(testCmd, []string{}); err == nil {
+		t.Error("expected error with no config")
+	}
+}
+
+func TestRunTest_JSONOutput(t *testing.T) {
+	oldEnvName, oldOutput := envName, outputFormat
+	defer func() { envName, outputFormat = oldEnvName, oldOutput }()
+	envName = "dev"
+	outputFormat = outputJSON
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_a.sql"),
+		[]byte("-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;"), 0644)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000002_b.sql"),
+		[]byte("-- +migrate UP\nALTER TABLE a ADD COLUMN name TEXT;\n-- +migrate DOWN\nSELECT 1;"), 0644)
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "sqlite3://" + filepath.Join(dir, "dev.db"),
+				"migrations_path": migrationsDir,
+			},
+		},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runTest(nil, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err == nil {
+		t.Fatal("expected error for migration with a no-op DOWN")
+	}
+
+	var result testOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if result.Passed || len(result.Migrations) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !result.Migrations[0].OK() || result.Migrations[1].OK() {
+		t.Errorf("expected only migration 2 to fail: %+v", result.Migrations)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dev.db")); err == nil {
+		t.Error("test must not touch the environment's database")
+	}
+}
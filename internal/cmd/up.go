@@ -10,27 +10,52 @@ import (
 	"github.com/cesc1802/janus/internal/ui"
 )
 
-var upSteps int
+var (
+	upSteps       int
+	upCanary      int
+	upParallelism int
+	upMaxFailures int
+)
 
 var upCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Apply pending migrations",
-	Long:  `Apply all pending migrations or specified number of steps.`,
-	RunE:  runUp,
+	Long: `Apply all pending migrations or specified number of steps.
+
+For an environment with targets (a fleet of databases), the canary targets
+are migrated first, then the rest in parallel. The rollout stops when a
+canary fails or more than max_failures targets fail, and ends with a
+per-target summary.`,
+	RunE: runUp,
 }
 
 func init() {
 	upCmd.Flags().IntVar(&upSteps, "steps", 0, "Number of migrations to apply (0 = all)")
+	upCmd.Flags().IntVar(&upCanary, "canary", 0, "Fleet: number of canary targets migrated first (default from config, else 1)")
+	upCmd.Flags().IntVar(&upParallelism, "parallelism", 0, "Fleet: targets migrated at once after the canary (default from config, else 4)")
+	upCmd.Flags().IntVar(&upMaxFailures, "max-failures", 0, "Fleet: failed targets tolerated before the rollout stops (default from config, else 0)")
 	rootCmd.AddCommand(upCmd)
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
-	mg, err := migrator.New(envName)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if fleet, err := migrator.IsFleet(cfg, envName); err == nil && fleet {
+		return runFleetUp(cmd, cfg)
+	}
+
+	mg, err := openMigratorToApply(cfg, envName)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = mg.Close() }()
 
+	if err := attachProgress(mg); err != nil {
+		return err
+	}
+
 	// Get status before migration
 	status, err := mg.Status()
 	if err != nil {
@@ -43,14 +68,14 @@ func runUp(cmd *cobra.Command, args []string) error {
 	}
 
 	// Show what will happen
-	fmt.Printf("Environment: %s\n", envName)
-	fmt.Printf("Pending migrations: %d\n", status.Pending)
+	fmt.Fprintf(ui.Output, "Environment: %s\n", envName)
+	fmt.Fprintf(ui.Output, "Pending migrations: %d\n", status.Pending)
 	if upSteps > 0 {
-		fmt.Printf("Will apply: %d migration(s)\n", upSteps)
+		fmt.Fprintf(ui.Output, "Will apply: %d migration(s)\n", upSteps)
 	} else {
-		fmt.Printf("Will apply: all %d migration(s)\n", status.Pending)
+		fmt.Fprintf(ui.Output, "Will apply: all %d migration(s)\n", status.Pending)
 	}
-	fmt.Println()
+	fmt.Fprintln(ui.Output)
 
 	// Confirmation logic
 	if !AutoApprove() {
@@ -75,11 +100,14 @@ func runUp(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := mg.Up(upSteps); err != nil {
+	if err := runInterruptible(mg, func() error { return mg.Up(upSteps) }); err != nil {
 		if err == migrate.ErrNoChange {
 			ui.Info("No migrations to apply")
 			return nil
 		}
+		if isInterrupted(err) {
+			return err
+		}
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
@@ -91,7 +119,8 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	applied := newStatus.Applied - status.Applied
 	ui.Success(fmt.Sprintf("Applied %d migration(s)", applied))
-	fmt.Printf("Current version: %d\n", newStatus.Version)
+	fmt.Fprintf(ui.Output, "Current version: %d\n", newStatus.Version)
+	writeSchemaSnapshot(mg)
 
 	return nil
 }
@@ -3,10 +3,6 @@ package cmd
 import (
 	"bytes"
 	"testing"
-
-	"github.com/spf13/viper"
-
-	"github.com/cesc1802/janus/internal/config"
 )
 
 func TestUpCmd_Registered(t *testing.T) {
@@ -35,8 +31,7 @@ func TestUpCmd_Flags(t *testing.T) {
 }
 
 func TestUpCmd_NoConfig(t *testing.T) {
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	// Don't set any config
 	envName = "test"
@@ -3,10 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
 
-	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/lint"
+	"github.com/cesc1802/janus/internal/migrator"
 	"github.com/cesc1802/janus/internal/source/singlefile"
 )
 
@@ -15,6 +17,9 @@ var validateCmd = &cobra.Command{
 	Short: "Validate configuration and migration files",
 	Long: `Validate configuration file and migration files for syntax errors.
 
+Also runs the 'janus lint' rules: findings with severity error fail
+validation, warnings are listed.
+
 Examples:
   janus validate
   janus validate --env=prod`,
@@ -25,17 +30,44 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 }
 
+// validateOutput is the --output schema for validate
+type validateOutput struct {
+	Valid        bool                `json:"valid" yaml:"valid"`
+	Environments []validateEnvOutput `json:"environments" yaml:"environments"`
+	Errors       []string            `json:"errors" yaml:"errors"`
+	Warnings     []string            `json:"warnings" yaml:"warnings"`
+}
+
+// validateEnvOutput summarizes the migrations checked for one environment
+type validateEnvOutput struct {
+	Name       string `json:"name" yaml:"name"`
+	Migrations int    `json:"migrations" yaml:"migrations"`
+}
+
 func runValidate(cmd *cobra.Command, args []string) error {
-	var errors []string
-	var warnings []string
+	structured, err := structuredOutput()
+	if err != nil {
+		return err
+	}
+
+	// Progress lines are only shown in text mode
+	logf := func(format string, a ...interface{}) {
+		if !structured {
+			fmt.Printf(format, a...)
+		}
+	}
+
+	errors := []string{}
+	warnings := []string{}
+	checked := []validateEnvOutput{}
 
 	// 1. Validate config
-	fmt.Println("Validating configuration...")
-	cfg, err := config.Load()
+	logf("Validating configuration...\n")
+	cfg, err := loadConfig()
 	if err != nil {
 		errors = append(errors, fmt.Sprintf("Config: %v", err))
 	} else {
-		fmt.Printf("  Found %d environment(s)\n", len(cfg.Environments))
+		logf("  Found %d environment(s)\n", len(cfg.Environments))
 	}
 
 	// 2. Validate migrations for specified env or all envs if none specified
@@ -48,12 +80,13 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		for name := range cfg.Environments {
 			envs = append(envs, name)
 		}
+		sort.Strings(envs)
 	}
 
 	for _, env := range envs {
-		fmt.Printf("\nValidating migrations for '%s'...\n", env)
+		logf("\nValidating migrations for '%s'...\n", env)
 
-		envCfg, err := config.GetEnv(env)
+		envCfg, err := cfg.Env(env)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Env %s: %v", env, err))
 			continue
@@ -99,7 +132,8 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			v, err = driver.Next(v)
 		}
 
-		fmt.Printf("  Found %d migration(s)\n", count)
+		logf("  Found %d migration(s)\n", count)
+		checked = append(checked, validateEnvOutput{Name: env, Migrations: count})
 
 		if emptyUp > 0 {
 			warnings = append(warnings, fmt.Sprintf("Env %s: %d migration(s) with empty UP section", env, emptyUp))
@@ -107,6 +141,35 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		if emptyDown > 0 {
 			warnings = append(warnings, fmt.Sprintf("Env %s: %d migration(s) with empty DOWN section", env, emptyDown))
 		}
+
+		// Lint findings: severity error fails validation
+		findings, _, err := migrator.Lint(cfg, env)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Env %s: lint: %v", env, err))
+			continue
+		}
+		for _, f := range findings {
+			if f.Severity == lint.SeverityError {
+				errors = append(errors, lintMessage(env, f))
+			} else {
+				warnings = append(warnings, lintMessage(env, f))
+			}
+		}
+	}
+
+	if structured {
+		if err := writeStructured(validateOutput{
+			Valid:        len(errors) == 0,
+			Environments: checked,
+			Errors:       errors,
+			Warnings:     warnings,
+		}); err != nil {
+			return err
+		}
+		if len(errors) > 0 {
+			return fmt.Errorf("validation failed with %d error(s)", len(errors))
+		}
+		return nil
 	}
 
 	// Print summary
@@ -1,12 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
-	"github.com/spf13/viper"
-
 	"github.com/cesc1802/janus/internal/config"
 )
 
@@ -17,8 +17,7 @@ func TestRunValidate_NoConfig(t *testing.T) {
 	envName = "dev"
 
 	// Reset config state
-	config.ResetForTesting()
-	viper.Reset()
+	withoutConfig(t)
 
 	// Run validate without config loaded
 	err := runValidate(nil, nil)
@@ -49,18 +48,18 @@ DROP TABLE IF EXISTS test;
 `
 	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_test.sql"), []byte(migrationContent), 0644)
 
-	// Setup viper with test config
-	config.ResetForTesting()
-	viper.Reset()
-	viper.Set("environments", map[string]interface{}{
-		"dev": map[string]interface{}{
-			"database_url":    "postgres://localhost/test",
-			"migrations_path": migrationsDir,
+	// Setup test config
+	path := useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "postgres://localhost/test",
+				"migrations_path": migrationsDir,
+			},
 		},
 	})
 
 	// Load config
-	_, err := config.Load()
+	_, err := config.Load(path)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -81,18 +80,18 @@ func TestRunValidate_MissingMigrationsPath(t *testing.T) {
 	dir := t.TempDir()
 	nonExistentPath := filepath.Join(dir, "nonexistent")
 
-	// Setup viper with invalid migrations path
-	config.ResetForTesting()
-	viper.Reset()
-	viper.Set("environments", map[string]interface{}{
-		"dev": map[string]interface{}{
-			"database_url":    "postgres://localhost/test",
-			"migrations_path": nonExistentPath,
+	// Setup test config
+	path := useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "postgres://localhost/test",
+				"migrations_path": nonExistentPath,
+			},
 		},
 	})
 
 	// Load config
-	_, err := config.Load()
+	_, err := config.Load(path)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -123,18 +122,18 @@ DROP TABLE IF EXISTS test;
 `
 	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_empty_up.sql"), []byte(migrationContent), 0644)
 
-	// Setup viper
-	config.ResetForTesting()
-	viper.Reset()
-	viper.Set("environments", map[string]interface{}{
-		"dev": map[string]interface{}{
-			"database_url":    "postgres://localhost/test",
-			"migrations_path": migrationsDir,
+	// Setup test config
+	path := useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "postgres://localhost/test",
+				"migrations_path": migrationsDir,
+			},
 		},
 	})
 
 	// Load config
-	_, err := config.Load()
+	_, err := config.Load(path)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -146,3 +145,49 @@ DROP TABLE IF EXISTS test;
 		t.Logf("Validate returned error (may include warnings): %v", err)
 	}
 }
+
+func TestRunValidate_JSONOutput(t *testing.T) {
+	oldEnvName, oldOutput := envName, outputFormat
+	defer func() { envName, outputFormat = oldEnvName, oldOutput }()
+	envName = ""
+	outputFormat = outputJSON
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	_ = os.WriteFile(filepath.Join(migrationsDir, "000001_test.sql"), []byte("-- +migrate UP\nCREATE TABLE t (id INT);"), 0644)
+
+	useConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"dev": map[string]interface{}{
+				"database_url":    "postgres://localhost/test",
+				"migrations_path": migrationsDir,
+			},
+		},
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runValidate(nil, nil)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runValidate() error: %v", err)
+	}
+
+	var result validateOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if !result.Valid || len(result.Environments) != 1 || result.Environments[0].Migrations != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected empty DOWN warning, got: %v", result.Warnings)
+	}
+}
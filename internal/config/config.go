@@ -1,96 +1,584 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"sync"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config represents the root configuration
 type Config struct {
+	// File is the path the config was loaded from ("" when read from an
+	// io.Reader)
+	File string `mapstructure:"-"`
+	// Layers are the files merged over File, in order (see LoadLayered)
+	Layers []string `mapstructure:"-"`
+
 	Environments map[string]Environment `mapstructure:"environments" validate:"required,min=1,dive"`
-	Defaults     Defaults               `mapstructure:"defaults"`
+	// Defaults holds values for every environment that neither sets them
+	// nor extends another environment. Extends is not allowed here.
+	Defaults Environment `mapstructure:"defaults"`
+	// Lint maps lint rule names to a severity: error, warning or off
+	Lint map[string]string `mapstructure:"lint"`
+	// PromotionOrder lists environments in the order changes reach them
+	PromotionOrder []string `mapstructure:"promotion_order"`
+
+	// origins maps environment name -> key -> where the value came from
+	origins map[string]map[string]string
+	// envErrs holds the errors Env reports for environments whose settings
+	// reference unset variables or unreadable secret files
+	envErrs map[string]error
 }
 
 // Environment represents per-environment configuration
 type Environment struct {
-	DatabaseURL         string `mapstructure:"database_url" validate:"required"`
+	// Extends names an environment whose resolved values this one inherits
+	// in place of Defaults; keys set here override them
+	Extends string `mapstructure:"extends"`
+	// DatabaseURL is required unless Targets or Connection is set. An unset
+	// ${VAR} in it is an error of the environment.
+	DatabaseURL string `mapstructure:"database_url" expand:"strict"`
+	// Connection builds DatabaseURL from driver, host, port, ... instead
+	Connection          `mapstructure:",squash"`
 	MigrationsPath      string `mapstructure:"migrations_path"`
 	RequireConfirmation bool   `mapstructure:"require_confirmation"`
+	// ShadowDatabaseURL is a scratch database that 'janus test' and
+	// 'janus create --from-schema' may wipe
+	ShadowDatabaseURL string `mapstructure:"shadow_database_url"`
+	// SchemaFile receives a normalized schema snapshot after up, down and goto
+	SchemaFile string `mapstructure:"schema_file"`
+	// StatementTimeout and LockTimeout are applied to the database session
+	// for each migration (0 = no limit). Postgres supports both, MySQL only
+	// LockTimeout, SQLite neither.
+	StatementTimeout time.Duration `mapstructure:"statement_timeout" validate:"gte=0"`
+	LockTimeout      time.Duration `mapstructure:"lock_timeout" validate:"gte=0"`
+	// AllowReset enables reset and fresh when RequireConfirmation is set
+	AllowReset bool  `mapstructure:"allow_reset"`
+	Hooks      Hooks `mapstructure:"hooks"`
+	// Targets turns the environment into a fleet of databases migrated
+	// together by up, in place of DatabaseURL
+	Targets Targets `mapstructure:"targets"`
+	// Schemas runs the migrations once per Postgres schema of the database
+	Schemas Schemas `mapstructure:"schemas"`
 }
 
-// Defaults represents default configuration values
-type Defaults struct {
-	MigrationsPath      string `mapstructure:"migrations_path"`
-	RequireConfirmation bool   `mapstructure:"require_confirmation"`
+// Schemas selects the Postgres schemas of a schema-per-tenant database.
+// Exactly one of List or Pattern is set.
+type Schemas struct {
+	// List names schemas explicitly; missing ones are created by the
+	// commands that apply migrations
+	List []string `mapstructure:"list"`
+	// Pattern matches existing schemas, with * and ? wildcards
+	Pattern string `mapstructure:"pattern"`
+}
+
+// IsSet reports whether the environment migrates per schema
+func (s Schemas) IsSet() bool {
+	return len(s.List) > 0 || s.Pattern != ""
+}
+
+// Targets lists the databases of a fleet environment and how up rolls
+// migrations out to them. Exactly one of List, File or Query names them.
+type Targets struct {
+	List []Target `mapstructure:"list"`
+	// File holds one target per line: a database URL, optionally preceded
+	// by a name. Blank lines and lines starting with # are ignored.
+	File string `mapstructure:"file"`
+	// Query returns the targets from a control database
+	Query TargetQuery `mapstructure:"query"`
+	// Canary is how many targets are migrated first, on their own
+	Canary *int `mapstructure:"canary" validate:"omitempty,gte=0"`
+	// Parallelism is how many targets are migrated at once after the canary
+	Parallelism int `mapstructure:"parallelism" validate:"gte=0"`
+	// MaxFailures is how many targets may fail before the rollout stops
+	MaxFailures int `mapstructure:"max_failures" validate:"gte=0"`
+}
+
+// Target is one database of a fleet
+type Target struct {
+	Name        string `mapstructure:"name" json:"name" yaml:"name"`
+	DatabaseURL string `mapstructure:"database_url" json:"-" yaml:"-"`
+}
+
+// TargetQuery selects targets from a control database. SQL must return
+// either one column (the database URL) or two (name, database URL).
+type TargetQuery struct {
+	DatabaseURL string `mapstructure:"database_url"`
+	SQL         string `mapstructure:"sql"`
+}
+
+// Rollout defaults for fleet environments
+const (
+	DefaultCanary      = 1
+	DefaultParallelism = 4
+)
+
+// IsSet reports whether any target source is configured
+func (t Targets) IsSet() bool {
+	return len(t.List) > 0 || t.File != "" || t.Query.SQL != "" || t.Query.DatabaseURL != ""
 }
 
-var (
-	cfg     *Config
-	cfgOnce sync.Once
-	cfgErr  error
+// CanaryCount returns the configured canary size, or DefaultCanary
+func (t Targets) CanaryCount() int {
+	if t.Canary == nil {
+		return DefaultCanary
+	}
+	return *t.Canary
+}
+
+// Hook stages, as named in the config file
+const (
+	HookBeforeUp   = "before_up"
+	HookAfterUp    = "after_up"
+	HookBeforeDown = "before_down"
+	HookAfterDown  = "after_down"
+	HookOnFailure  = "on_failure"
 )
 
-// Load reads config from viper, expands env vars, applies defaults, validates.
-// Thread-safe: uses sync.Once to ensure single initialization.
-func Load() (*Config, error) {
-	cfgOnce.Do(func() {
-		var c Config
-		if err := viper.Unmarshal(&c); err != nil {
-			cfgErr = fmt.Errorf("unmarshal config: %w", err)
-			return
+// HookStages lists every hook stage in config order
+var HookStages = []string{HookBeforeUp, HookAfterUp, HookBeforeDown, HookAfterDown, HookOnFailure}
+
+// Hooks lists what runs before and after migrations
+type Hooks struct {
+	BeforeUp   []Hook `mapstructure:"before_up"`
+	AfterUp    []Hook `mapstructure:"after_up"`
+	BeforeDown []Hook `mapstructure:"before_down"`
+	AfterDown  []Hook `mapstructure:"after_down"`
+	OnFailure  []Hook `mapstructure:"on_failure"`
+}
+
+// Hook is either a shell command or a SQL file to run against the database
+type Hook struct {
+	// Command is run by a shell, which expands $VAR and ${VAR} itself
+	// (including the JANUS_* variables), so janus leaves it as written
+	Command string `mapstructure:"command" expand:"-"`
+	SQL     string `mapstructure:"sql"`
+}
+
+// Stage returns the hooks configured for a stage
+func (h Hooks) Stage(stage string) []Hook {
+	switch stage {
+	case HookBeforeUp:
+		return h.BeforeUp
+	case HookAfterUp:
+		return h.AfterUp
+	case HookBeforeDown:
+		return h.BeforeDown
+	case HookAfterDown:
+		return h.AfterDown
+	case HookOnFailure:
+		return h.OnFailure
+	}
+	return nil
+}
+
+// FileNames are the config files looked for when no path is given, in
+// order of preference
+var FileNames = []string{"janus.yaml", "janus.yml", "janus.json", "janus.toml"}
+
+// EnvVar names the environment variable that points at the config file
+// when --config is not given
+const EnvVar = "JANUS_CONFIG"
+
+// ErrNotFound is returned by Find when no config file exists
+var ErrNotFound = errors.New("no config file found (looking for janus.yaml)")
+
+// Find returns the config file in dir or the closest parent directory,
+// trying FileNames in order in each
+func Find(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
 		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", ErrNotFound
+		}
+		abs, dir = parent, parent
+	}
+}
+
+// Load reads the config file at path, expands env vars, applies defaults
+// and validates. The format follows the file extension (YAML by default).
+// Each call returns an independent Config.
+func Load(path string) (*Config, error) {
+	return load([]string{path}, "")
+}
+
+// LoadOptions selects the layers LoadLayered merges and how relative paths
+// are resolved
+type LoadOptions struct {
+	// Env also merges the <name>.<Env> layer
+	Env string
+	// Dir resolves relative file paths in the config (migrations_path,
+	// schema_file, hook SQL files, certificates, SQLite databases, ...)
+	// against this directory instead of the working directory
+	Dir string
+}
+
+// LoadLayered is Load with local overrides: it merges <name>.local.<ext>
+// and then <name>.<env>.<ext>, from the directory of path, over it when
+// they exist. Maps merge key by key; any other value is replaced.
+func LoadLayered(path string, opts LoadOptions) (*Config, error) {
+	return load(LayerFiles(path, opts.Env), opts.Dir)
+}
+
+// LayerFiles returns path followed by its existing layers, in merge order
+func LayerFiles(path, env string) []string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	files := []string{path}
+	for _, layer := range []string{"local", env} {
+		if layer == "" {
+			continue
+		}
+		file := stem + "." + layer + ext
+		if info, err := os.Stat(file); err == nil && !info.IsDir() && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
 
-		// Apply defaults + expand env vars
-		for name, env := range c.Environments {
-			if env.MigrationsPath == "" {
-				env.MigrationsPath = c.Defaults.MigrationsPath
+// load reads and merges files in order, then decodes them
+func load(files []string, dir string) (*Config, error) {
+	v := viper.New()
+	for i, file := range files {
+		v.SetConfigFile(file)
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+		if filepath.Ext(file) == "" {
+			v.SetConfigType("yaml")
+		}
+		read := v.MergeInConfig
+		if i == 0 {
+			read = v.ReadInConfig
+		}
+		if err := read(); err != nil {
+			return nil, fmt.Errorf("read config %s: %w", file, err)
+		}
+	}
+	c, err := decode(v, dir)
+	if err != nil {
+		return nil, err
+	}
+	c.File, c.Layers = files[0], files[1:]
+	return c, nil
+}
+
+// Read is Load for a YAML config read from r
+func Read(r io.Reader) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(r); err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return decode(v, "")
+}
+
+// decode expands the config held by v, resolves defaults and extends,
+// unmarshals it and validates. Relative paths are resolved against dir
+// unless it is "".
+func decode(v *viper.Viper, dir string) (*Config, error) {
+	// Work on the raw values: expansion errors can then name the config
+	// path as written, and a key set to its zero value
+	// (require_confirmation: false) still overrides an inherited one
+	all := v.AllSettings()
+	defaults, _ := all["defaults"].(map[string]interface{})
+	// AllSettings drops environments with no keys of their own, such as
+	// "dev: {}" relying on defaults
+	rawEnvs, _ := v.Get("environments").(map[string]interface{})
+	delete(all, "defaults")
+	delete(all, "environments")
+	expanded, err := expandSettings(all, reflect.TypeOf(Config{}), "", dir)
+	if err != nil {
+		return nil, err
+	}
+	settings := expanded.(map[string]interface{})
+
+	// Settings that fail to expand are reported by Env for the environments
+	// using them, so a variable only prod needs does not stop dev. failed
+	// maps an origin (defaults or environments.<name>) -> key -> error.
+	failed := make(map[string]map[string]error)
+	expandEnv := func(origin string, raw interface{}) error {
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return expandFields(values, reflect.TypeOf(Environment{}), origin, dir, func(key string, err error) error {
+			if failed[origin] == nil {
+				failed[origin] = make(map[string]error)
 			}
-			if env.MigrationsPath == "" {
-				env.MigrationsPath = "./migrations"
+			failed[origin][key] = err
+			return nil
+		})
+	}
+	if err := expandEnv(OriginDefaults, defaults); err != nil {
+		return nil, err
+	}
+	for name, raw := range rawEnvs {
+		if err := expandEnv("environments."+name, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := defaults["extends"]; ok {
+		return nil, errors.New("defaults: extends is not allowed")
+	}
+	envs, origins, err := inherit(defaults, rawEnvs)
+	if err != nil {
+		return nil, err
+	}
+	if defaults != nil {
+		settings["defaults"] = defaults
+	}
+	settings["environments"] = envs
+
+	resolved := viper.New()
+	if err := resolved.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("resolve config: %w", err)
+	}
+	c := Config{origins: origins, envErrs: make(map[string]error)}
+	if err := resolved.Unmarshal(&c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	// viper drops keys without a value, and with them an environment whose
+	// only setting failed to expand
+	for name := range envs {
+		if _, ok := c.Environments[name]; !ok {
+			if c.Environments == nil {
+				c.Environments = make(map[string]Environment)
 			}
-			env.DatabaseURL = ExpandEnvVars(env.DatabaseURL)
+			c.Environments[name] = Environment{}
+		}
+	}
+
+	if dir != "" {
+		c.Defaults.rebase(dir)
+	}
+
+	// Apply built-in defaults
+	for name, env := range c.Environments {
+		if env.MigrationsPath == "" {
+			env.MigrationsPath = "./migrations"
+			c.origins[name]["migrations_path"] = OriginBuiltin
+		}
+		if dir != "" {
+			env.rebase(dir)
+		}
+		if err := failedSetting(failed, c.origins[name]); err != nil {
+			c.envErrs[name] = fmt.Errorf("environment %q: %w", name, err)
 			c.Environments[name] = env
+			continue
+		}
+		if env.Connection.IsSet() {
+			if env.DatabaseURL != "" {
+				return nil, fmt.Errorf("environment %q: set either database_url or connection settings (driver, host, ...), not both", name)
+			}
+			url, err := env.Connection.URL()
+			if err != nil {
+				return nil, fmt.Errorf("environment %q: %w", name, err)
+			}
+			env.DatabaseURL = url
+			c.origins[name]["database_url"] = c.origins[name]["driver"]
+		}
+		if env.Targets.Parallelism == 0 {
+			env.Targets.Parallelism = DefaultParallelism
 		}
+		c.Environments[name] = env
+	}
+
+	if err := Validate(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
 
-		if err := Validate(&c); err != nil {
-			cfgErr = err
-			return
+// rebase resolves the environment's relative file paths against dir
+func (e *Environment) rebase(dir string) {
+	paths := []*string{&e.MigrationsPath, &e.SchemaFile, &e.Targets.File, &e.SSLRootCert, &e.SSLCert, &e.SSLKey}
+	if driver := strings.ToLower(e.Driver); driver == "sqlite3" || driver == "sqlite" {
+		paths = append(paths, &e.Database)
+	}
+	for _, stage := range HookStages {
+		for i := range e.Hooks.Stage(stage) {
+			paths = append(paths, &e.Hooks.Stage(stage)[i].SQL)
 		}
+	}
+	for _, p := range paths {
+		*p = rebasePath(*p, dir)
+	}
+	e.DatabaseURL = rebaseSQLiteURL(e.DatabaseURL, dir)
+	e.ShadowDatabaseURL = rebaseSQLiteURL(e.ShadowDatabaseURL, dir)
+}
 
-		cfg = &c
-	})
+// rebasePath joins a relative path to dir; "" and absolute paths are kept
+func rebasePath(path, dir string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
 
-	return cfg, cfgErr
+// rebaseSQLiteURL rebases the file path of a sqlite3:// URL; other URLs
+// are returned unchanged
+func rebaseSQLiteURL(url, dir string) string {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok || (scheme != "sqlite3" && scheme != "sqlite") {
+		return url
+	}
+	file, query, hasQuery := strings.Cut(rest, "?")
+	url = scheme + "://" + rebasePath(file, dir)
+	if hasQuery {
+		url += "?" + query
+	}
+	return url
 }
 
-// Get returns the loaded config (nil if not loaded)
-func Get() *Config {
-	return cfg
+// Where a resolved environment value came from, as reported by Origin.
+// A value set on an environment, or on one it extends, reports
+// "environments.<name>".
+const (
+	OriginDefaults = "defaults"
+	OriginBuiltin  = "built-in default"
+)
+
+// inherit resolves each environment's raw values: those of the environment
+// it extends (or defaults, if none), overridden key by key with its own.
+// Nested blocks such as hooks and targets are replaced as a whole. It also
+// returns where each key of each environment came from.
+func inherit(defaults, envs map[string]interface{}) (map[string]interface{}, map[string]map[string]string, error) {
+	own := make(map[string]map[string]interface{}, len(envs))
+	names := make([]string, 0, len(envs))
+	for name, raw := range envs {
+		values, ok := raw.(map[string]interface{})
+		if raw != nil && !ok {
+			return nil, nil, fmt.Errorf("environment %q: expected a mapping of settings", name)
+		}
+		own[name] = values
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]interface{}, len(envs))
+	origins := make(map[string]map[string]string, len(envs))
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		if _, done := resolved[name]; done {
+			return nil
+		}
+		for _, n := range chain {
+			if n == name {
+				return fmt.Errorf("environment %q: extends cycle: %s", name, strings.Join(append(chain, name), " -> "))
+			}
+		}
+
+		values := make(map[string]interface{})
+		from := make(map[string]string)
+		base, ok := own[name]["extends"].(string)
+		if raw, set := own[name]["extends"]; set && !ok {
+			return fmt.Errorf("environment %q: extends must be an environment name, got %v", name, raw)
+		}
+		if base != "" {
+			if _, exists := own[base]; !exists {
+				return fmt.Errorf("environment %q: extends unknown environment %q", name, base)
+			}
+			if err := resolve(base, append(chain, name)); err != nil {
+				return err
+			}
+			for k, val := range resolved[base].(map[string]interface{}) {
+				if k != "extends" {
+					values[k], from[k] = val, origins[base][k]
+				}
+			}
+		} else {
+			for k, val := range defaults {
+				values[k], from[k] = val, OriginDefaults
+			}
+		}
+		// database_url and the connection settings are alternatives: setting
+		// one drops the other if inherited
+		if _, ok := own[name]["database_url"]; ok {
+			for _, k := range connectionKeys {
+				delete(values, k)
+				delete(from, k)
+			}
+		} else {
+			for _, k := range connectionKeys {
+				if _, ok := own[name][k]; ok {
+					delete(values, "database_url")
+					delete(from, "database_url")
+					break
+				}
+			}
+		}
+		for k, val := range own[name] {
+			values[k], from[k] = val, "environments."+name
+		}
+		resolved[name], origins[name] = values, from
+		return nil
+	}
+	for _, name := range names {
+		if err := resolve(name, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resolved, origins, nil
 }
 
-// GetEnv retrieves environment config by name.
-// Returns a copy of the environment config (modifications won't affect original).
-func GetEnv(name string) (Environment, error) {
-	if cfg == nil {
-		return Environment{}, fmt.Errorf("config not loaded")
+// failedSetting returns the expansion error of the first key, in name
+// order, whose value came from a setting that failed to expand
+func failedSetting(failed map[string]map[string]error, from map[string]string) error {
+	keys := make([]string, 0, len(from))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := failed[from[key]][key]; err != nil {
+			return err
+		}
 	}
-	env, ok := cfg.Environments[name]
+	return nil
+}
+
+// Origin reports where an environment's resolved value for key (as written
+// in the config file, e.g. "database_url") came from: OriginDefaults,
+// OriginBuiltin or "environments.<name>". Returns "" if the key is unset or
+// the Config was not loaded from a file or reader.
+func (c *Config) Origin(env, key string) string {
+	return c.origins[env][key]
+}
+
+// Env retrieves environment config by name.
+// Returns a copy of the environment config (modifications won't affect original).
+func (c *Config) Env(name string) (Environment, error) {
+	env, ok := c.Environments[name]
 	if !ok {
-		available := make([]string, 0, len(cfg.Environments))
-		for k := range cfg.Environments {
+		available := make([]string, 0, len(c.Environments))
+		for k := range c.Environments {
 			available = append(available, k)
 		}
+		sort.Strings(available)
 		return Environment{}, fmt.Errorf("environment %q not found (available: %v)", name, available)
 	}
+	if err := c.envErrs[name]; err != nil {
+		return Environment{}, err
+	}
 	return env, nil
 }
-
-// ResetForTesting resets the config state for testing purposes only.
-// Do not use in production code.
-func ResetForTesting() {
-	cfg = nil
-	cfgOnce = sync.Once{}
-	cfgErr = nil
-}
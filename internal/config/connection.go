@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Connection describes a database piece by piece, as an alternative to
+// writing database_url by hand. URL assembles it into the URL janus uses.
+type Connection struct {
+	// Driver is postgres, mysql or sqlite3
+	Driver   string `mapstructure:"driver"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port" validate:"gte=0,lte=65535"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	// Database is the database name, or the file path for sqlite3
+	Database string `mapstructure:"database"`
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or
+	// verify-full (allow and prefer are Postgres only)
+	SSLMode     string `mapstructure:"sslmode"`
+	SSLRootCert string `mapstructure:"sslrootcert"`
+	SSLCert     string `mapstructure:"sslcert"`
+	SSLKey      string `mapstructure:"sslkey"`
+	// Params are extra query parameters passed to the driver
+	Params map[string]string `mapstructure:"params"`
+}
+
+// connectionKeys are the config keys of Connection. An environment that
+// sets any of them does not inherit database_url, and vice versa.
+var connectionKeys = []string{
+	"driver", "host", "port", "user", "password", "database",
+	"sslmode", "sslrootcert", "sslcert", "sslkey", "params",
+}
+
+// Connection drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite3"
+)
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// IsSet reports whether any connection setting is configured
+func (c Connection) IsSet() bool {
+	return c.Driver != "" || c.Host != "" || c.Port != 0 || c.User != "" || c.Password != "" ||
+		c.Database != "" || c.SSLMode != "" || c.SSLRootCert != "" || c.SSLCert != "" ||
+		c.SSLKey != "" || len(c.Params) > 0
+}
+
+// URL validates the settings for their driver, checks that certificate
+// files exist, and returns the database URL. User and password are
+// escaped as the driver requires.
+func (c Connection) URL() (string, error) {
+	if c.Database == "" {
+		return "", fmt.Errorf("database is required")
+	}
+	if c.Password != "" && c.User == "" {
+		return "", fmt.Errorf("password requires user")
+	}
+	if c.SSLMode != "" && !sslModes[c.SSLMode] {
+		return "", fmt.Errorf("invalid sslmode %q (expected disable, allow, prefer, require, verify-ca or verify-full)", c.SSLMode)
+	}
+	for _, cert := range []struct{ key, file string }{
+		{"sslrootcert", c.SSLRootCert}, {"sslcert", c.SSLCert}, {"sslkey", c.SSLKey},
+	} {
+		if cert.file == "" {
+			continue
+		}
+		if info, err := os.Stat(cert.file); err != nil {
+			return "", fmt.Errorf("%s: %w", cert.key, err)
+		} else if info.IsDir() {
+			return "", fmt.Errorf("%s: %s is a directory", cert.key, cert.file)
+		}
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		return "", fmt.Errorf("sslcert and sslkey must be set together")
+	}
+
+	switch strings.ToLower(c.Driver) {
+	case "postgres", "postgresql":
+		return c.postgresURL()
+	case DriverMySQL:
+		return c.mysqlURL()
+	case "sqlite3", "sqlite":
+		return c.sqliteURL()
+	case "":
+		return "", fmt.Errorf("driver is required (postgres, mysql or sqlite3)")
+	}
+	return "", fmt.Errorf("unsupported driver %q (expected postgres, mysql or sqlite3)", c.Driver)
+}
+
+func (c Connection) postgresURL() (string, error) {
+	u := url.URL{Scheme: DriverPostgres, Host: c.address(), Path: "/" + c.Database}
+	if c.User != "" {
+		u.User = url.User(c.User)
+		if c.Password != "" {
+			u.User = url.UserPassword(c.User, c.Password)
+		}
+	}
+	query, err := c.query(map[string]string{
+		"sslmode":     c.SSLMode,
+		"sslrootcert": c.SSLRootCert,
+		"sslcert":     c.SSLCert,
+		"sslkey":      c.SSLKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = query
+	return u.String(), nil
+}
+
+func (c Connection) mysqlURL() (string, error) {
+	ssl := map[string]string{}
+	switch c.SSLMode {
+	case "allow", "prefer":
+		return "", fmt.Errorf("sslmode %s is not supported by mysql", c.SSLMode)
+	case "disable":
+		ssl["tls"] = "false"
+	case "require":
+		ssl["tls"] = "skip-verify"
+	case "verify-ca", "verify-full":
+		ssl["tls"] = "true"
+	}
+	if c.SSLRootCert != "" {
+		if c.SSLMode == "disable" {
+			return "", fmt.Errorf("sslrootcert cannot be used with sslmode disable")
+		}
+		// A named TLS config that golang-migrate registers from the
+		// x-tls-* parameters
+		ssl["tls"] = "custom"
+		ssl["x-tls-ca"] = c.SSLRootCert
+		ssl["x-tls-cert"] = c.SSLCert
+		ssl["x-tls-key"] = c.SSLKey
+		if c.SSLMode == "require" {
+			ssl["x-tls-insecure-skip-verify"] = "true"
+		}
+	} else if c.SSLCert != "" {
+		return "", fmt.Errorf("sslcert requires sslrootcert for mysql")
+	}
+	query, err := c.query(ssl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("mysql://")
+	if c.User != "" {
+		// golang-migrate query-unescapes both
+		b.WriteString(url.QueryEscape(c.User))
+		if c.Password != "" {
+			b.WriteString(":" + url.QueryEscape(c.Password))
+		}
+		b.WriteString("@")
+	}
+	b.WriteString("tcp(" + c.address() + ")/" + c.Database)
+	if query != "" {
+		b.WriteString("?" + query)
+	}
+	return b.String(), nil
+}
+
+func (c Connection) sqliteURL() (string, error) {
+	for _, setting := range []struct {
+		key string
+		set bool
+	}{
+		{"host", c.Host != ""}, {"port", c.Port != 0}, {"user", c.User != ""}, {"sslmode", c.SSLMode != ""},
+		{"sslrootcert", c.SSLRootCert != ""}, {"sslcert", c.SSLCert != ""},
+	} {
+		if setting.set {
+			return "", fmt.Errorf("%s is not supported by sqlite3", setting.key)
+		}
+	}
+	query, err := c.query(nil)
+	if err != nil {
+		return "", err
+	}
+	if query != "" {
+		return DriverSQLite + "://" + c.Database + "?" + query, nil
+	}
+	return DriverSQLite + "://" + c.Database, nil
+}
+
+// address returns host[:port], defaulting the host to localhost
+func (c Connection) address() string {
+	host := c.Host
+	if host == "" {
+		host = "localhost"
+	}
+	if c.Port == 0 {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.Port))
+}
+
+// query encodes Params plus the non-empty settings, which Params may not
+// repeat
+func (c Connection) query(settings map[string]string) (string, error) {
+	values := url.Values{}
+	for key, value := range c.Params {
+		values.Set(key, value)
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if settings[key] == "" {
+			continue
+		}
+		if _, ok := c.Params[key]; ok {
+			return "", fmt.Errorf("params.%s conflicts with the %s setting", key, key)
+		}
+		values.Set(key, settings[key])
+	}
+	return values.Encode(), nil
+}
@@ -1,20 +1,271 @@
 package config
 
 import (
+	"fmt"
 	"os"
-	"regexp"
+	"reflect"
+	"sort"
+	"strings"
 )
 
-var envVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+// Expand replaces environment variable references in s:
+//
+//	${VAR}             value of VAR; left as is if VAR is unset
+//	${VAR:-default}    value of VAR, or default if VAR is unset or empty
+//	${VAR:?message}    value of VAR; an error with message if unset or empty
+//	$${...}            a literal ${...}, not expanded
+func Expand(s string) (string, error) {
+	out, _, err := expand(s)
+	return out, err
+}
+
+// expand is Expand, also returning the unset variables it left as is
+func expand(s string) (out string, unset []string, err error) {
+	if !strings.Contains(s, "${") {
+		return s, nil, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return "", nil, fmt.Errorf("unterminated variable reference %q", s[i:])
+			}
+			val, set, err := expandVar(s[i+2 : i+end])
+			if err != nil {
+				return "", nil, err
+			}
+			if !set {
+				unset = append(unset, s[i+2:i+end])
+			}
+			b.WriteString(val)
+			i += end + 1
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String(), unset, nil
+}
+
+// expandVar resolves the inside of one ${...} reference. set is false for
+// an unset ${VAR}, which is returned as is.
+func expandVar(expr string) (val string, set bool, err error) {
+	name, op, arg := expr, "", ""
+	if i := strings.Index(expr, ":"); i != -1 {
+		name, op, arg = expr[:i], expr[i:min(i+2, len(expr))], expr[min(i+2, len(expr)):]
+	}
+	if name == "" {
+		return "", false, fmt.Errorf("empty variable name in ${%s}", expr)
+	}
+
+	val, set = os.LookupEnv(name)
+	switch op {
+	case "":
+		if !set {
+			return "${" + expr + "}", false, nil
+		}
+		return val, true, nil
+	case ":-":
+		if val == "" {
+			return arg, true, nil
+		}
+		return val, true, nil
+	case ":?":
+		if val == "" {
+			if arg == "" {
+				arg = "is required"
+			}
+			return "", false, fmt.Errorf("env var %s: %s", name, arg)
+		}
+		return val, true, nil
+	}
+	return "", false, fmt.Errorf("unsupported expansion ${%s} (expected ${VAR}, ${VAR:-default} or ${VAR:?message})", expr)
+}
+
+// fileSuffix marks a key whose value is read from a file, e.g.
+// database_url_file for database_url (Docker and Kubernetes secrets)
+const fileSuffix = "_file"
+
+// expandSettings walks raw config values alongside the struct type they
+// decode into. It expands every string value, except those of fields tagged
+// expand:"-", and replaces each <key>_file, where <key> is a string
+// setting, with the file's contents. Relative secret files are read from
+// dir unless it is "". Errors name the config path, e.g.
+// environments.prod.database_url.
+func expandSettings(raw interface{}, typ reflect.Type, path, dir string) (interface{}, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch v := raw.(type) {
+	case string:
+		s, err := Expand(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return s, nil
 
-// ExpandEnvVars replaces ${VAR} patterns with environment variable values.
-// If the env var is not set, the original pattern is preserved.
-func ExpandEnvVars(s string) string {
-	return envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
-		varName := match[2 : len(match)-1] // strip ${ and }
-		if val := os.Getenv(varName); val != "" {
-			return val
+	case []interface{}:
+		if typ.Kind() != reflect.Slice {
+			return v, nil
+		}
+		for i, item := range v {
+			expanded, err := expandSettings(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i), dir)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+
+	case map[string]interface{}:
+		if typ.Kind() == reflect.Map {
+			for key, item := range v {
+				expanded, err := expandSettings(item, typ.Elem(), joinPath(path, key), dir)
+				if err != nil {
+					return nil, err
+				}
+				v[key] = expanded
+			}
+			return v, nil
+		}
+		if typ.Kind() != reflect.Struct {
+			return v, nil
+		}
+		err := expandFields(v, typ, path, dir, func(_ string, err error) error { return err })
+		if err != nil {
+			return nil, err
 		}
-		return match // keep original if not found
-	})
+		return v, nil
+	}
+	return raw, nil
+}
+
+// expandStrict expands a setting tagged expand:"strict", for which an unset
+// ${VAR} is an error rather than left as is
+func expandStrict(s, path string) (string, error) {
+	out, unset, err := expand(s)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if len(unset) > 0 {
+		return "", fmt.Errorf("%s references unset env var %q", path, unset[0])
+	}
+	return out, nil
+}
+
+// expandFields expands the settings of a struct-typed mapping in place,
+// as expandSettings does. Each failure is passed to fail with the key it
+// concerns, whose value is then cleared; expansion stops at the first
+// error fail returns.
+func expandFields(v map[string]interface{}, typ reflect.Type, path, dir string, fail func(key string, err error) error) error {
+	fields := settingFields(typ)
+	for key, item := range v {
+		field, ok := fields[key]
+		if !ok || field.Tag.Get("expand") == "-" {
+			continue
+		}
+		var expanded interface{}
+		var err error
+		if s, ok := item.(string); ok && field.Tag.Get("expand") == "strict" {
+			expanded, err = expandStrict(s, joinPath(path, key))
+		} else {
+			expanded, err = expandSettings(item, field.Type, joinPath(path, key), dir)
+		}
+		if err != nil {
+			v[key] = nil
+			if err := fail(key, err); err != nil {
+				return err
+			}
+			continue
+		}
+		v[key] = expanded
+	}
+	// Secret file contents are taken verbatim, not expanded
+	var secrets []string
+	for key := range v {
+		target := strings.TrimSuffix(key, fileSuffix)
+		if _, known := fields[key]; known || target == key {
+			continue
+		}
+		if field, ok := fields[target]; ok && field.Type.Kind() == reflect.String && field.Tag.Get("expand") != "-" {
+			secrets = append(secrets, key)
+		}
+	}
+	sort.Strings(secrets)
+	for _, key := range secrets {
+		target := strings.TrimSuffix(key, fileSuffix)
+		secret, err := readSecret(v, key, target, path, dir)
+		delete(v, key)
+		v[target] = secret
+		if err != nil {
+			v[target] = nil
+			if err := fail(target, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readSecret reads the file named by the <target>_file setting key
+func readSecret(v map[string]interface{}, key, target, path, dir string) (string, error) {
+	if _, set := v[target]; set {
+		return "", fmt.Errorf("%s: set either %s or %s, not both", pathOrRoot(path), target, key)
+	}
+	file, _ := v[key].(string)
+	if file == "" {
+		return "", fmt.Errorf("%s: expected a file path", joinPath(path, key))
+	}
+	file, err := Expand(file)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", joinPath(path, key), err)
+	}
+	if dir != "" {
+		file = rebasePath(file, dir)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("%s: read secret: %w", joinPath(path, key), err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// settingFields maps a struct's config keys (mapstructure tags) to fields,
+// including those of squashed embedded structs
+func settingFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		switch {
+		case tag == ",squash":
+			for key, embedded := range settingFields(f.Type) {
+				fields[key] = embedded
+			}
+		case tag != "" && tag != "-":
+			fields[tag] = f
+		}
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
 }
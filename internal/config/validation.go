@@ -2,9 +2,12 @@ package config
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/cesc1802/janus/internal/lint"
 )
 
 // Validate validates the config struct and returns user-friendly errors
@@ -23,20 +26,110 @@ func Validate(c *Config) error {
 		return fmt.Errorf("config validation failed:\n  %s", strings.Join(errs, "\n  "))
 	}
 
-	// Custom: check for unexpanded env vars
+	seen := make(map[string]bool, len(c.PromotionOrder))
+	for _, name := range c.PromotionOrder {
+		if _, ok := c.Environments[name]; !ok {
+			return fmt.Errorf("promotion_order: unknown environment %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("promotion_order: environment %q listed twice", name)
+		}
+		seen[name] = true
+	}
+
+	if err := lint.ValidateSeverities(c.Lint); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
 	for name, env := range c.Environments {
-		if strings.HasPrefix(env.DatabaseURL, "${") && strings.HasSuffix(env.DatabaseURL, "}") {
-			varName := env.DatabaseURL[2 : len(env.DatabaseURL)-1]
-			return fmt.Errorf("environment %q: database_url references unset env var %q", name, varName)
+		// Reported by Env when the environment is used
+		if c.envErrs[name] != nil {
+			continue
+		}
+		if err := validateTargets(name, env); err != nil {
+			return err
+		}
+		if err := validateSchemas(name, env); err != nil {
+			return err
 		}
-		if strings.Contains(env.DatabaseURL, "${") {
-			return fmt.Errorf("environment %q: database_url contains unexpanded variable", name)
+		for _, stage := range HookStages {
+			for i, h := range env.Hooks.Stage(stage) {
+				if (h.Command == "") == (h.SQL == "") {
+					return fmt.Errorf("environment %q: hooks.%s[%d] must set exactly one of command or sql", name, stage, i)
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// validateTargets checks that an environment has exactly one of
+// database_url or targets, and that targets has exactly one source
+func validateTargets(name string, env Environment) error {
+	t := env.Targets
+	if !t.IsSet() {
+		if env.DatabaseURL == "" {
+			return fmt.Errorf("config validation failed:\n  Environments[%s].DatabaseURL is required", name)
+		}
+		return nil
+	}
+	if env.DatabaseURL != "" {
+		return fmt.Errorf("environment %q: set either database_url or targets, not both", name)
+	}
+
+	sources := 0
+	for _, set := range []bool{len(t.List) > 0, t.File != "", t.Query.SQL != "" || t.Query.DatabaseURL != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("environment %q: targets must set exactly one of list, file or query", name)
+	}
+	if (t.Query.SQL == "") != (t.Query.DatabaseURL == "") {
+		return fmt.Errorf("environment %q: targets.query needs both database_url and sql", name)
+	}
+	for i, target := range t.List {
+		if target.DatabaseURL == "" {
+			return fmt.Errorf("environment %q: targets.list[%d].database_url is required", name, i)
+		}
+	}
+	return nil
+}
+
+// validateSchemas checks a schema-per-tenant setup: one selector, valid
+// names and a Postgres database
+func validateSchemas(name string, env Environment) error {
+	s := env.Schemas
+	if !s.IsSet() {
+		return nil
+	}
+	if env.Targets.IsSet() {
+		return fmt.Errorf("environment %q: set either targets or schemas, not both", name)
+	}
+	if !strings.HasPrefix(env.DatabaseURL, "postgres://") && !strings.HasPrefix(env.DatabaseURL, "postgresql://") {
+		return fmt.Errorf("environment %q: schemas requires a Postgres database_url", name)
+	}
+	if len(s.List) > 0 && s.Pattern != "" {
+		return fmt.Errorf("environment %q: schemas must set one of list or pattern, not both", name)
+	}
+	if _, err := path.Match(s.Pattern, ""); err != nil {
+		return fmt.Errorf("environment %q: schemas.pattern %q: %w", name, s.Pattern, err)
+	}
+	seen := make(map[string]bool, len(s.List))
+	for i, schema := range s.List {
+		if schema == "" {
+			return fmt.Errorf("environment %q: schemas.list[%d] is empty", name, i)
+		}
+		if seen[schema] {
+			return fmt.Errorf("environment %q: schema %q listed twice", name, schema)
+		}
+		seen[schema] = true
+	}
+	return nil
+}
+
 func formatValidationError(e validator.FieldError) string {
 	field := e.StructNamespace()
 	// Simplify field names for better UX
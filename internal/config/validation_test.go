@@ -53,41 +53,55 @@ func TestValidate_MissingDatabaseURL(t *testing.T) {
 	}
 }
 
-func TestValidate_UnexpandedEnvVar(t *testing.T) {
-	c := &Config{
-		Environments: map[string]Environment{
-			"prod": {
-				DatabaseURL:    "${UNSET_DATABASE_URL}",
-				MigrationsPath: "./migrations",
-			},
-		},
-	}
+func TestLoad_UnexpandedEnvVar(t *testing.T) {
+	path := setupTestConfig(t, `
+environments:
+  prod:
+    database_url: "${UNSET_DATABASE_URL}"
+`)
 
-	err := Validate(c)
-	if err == nil {
-		t.Error("Validate() should return error for unexpanded env var")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
 	}
-	if !strings.Contains(err.Error(), "UNSET_DATABASE_URL") {
-		t.Errorf("error should mention the env var name, got: %v", err)
+	if _, err := cfg.Env("prod"); err == nil || !strings.Contains(err.Error(), "UNSET_DATABASE_URL") {
+		t.Errorf("Env() should name the unset env var, got: %v", err)
 	}
 }
 
-func TestValidate_PartiallyUnexpandedEnvVar(t *testing.T) {
-	c := &Config{
-		Environments: map[string]Environment{
-			"prod": {
-				DatabaseURL:    "postgres://${DB_USER}@localhost:5432/db",
-				MigrationsPath: "./migrations",
-			},
-		},
+func TestLoad_PartiallyUnexpandedEnvVar(t *testing.T) {
+	path := setupTestConfig(t, `
+environments:
+  prod:
+    database_url: "postgres://${UNSET_DB_USER}@localhost:5432/db"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if _, err := cfg.Env("prod"); err == nil || !strings.Contains(err.Error(), `references unset env var "UNSET_DB_USER"`) {
+		t.Errorf("Env() should report the unset env var, got: %v", err)
 	}
+}
 
-	err := Validate(c)
-	if err == nil {
-		t.Error("Validate() should return error for partially unexpanded env var")
+func TestLoad_EscapedReferenceInDatabaseURL(t *testing.T) {
+	path := setupTestConfig(t, `
+environments:
+  prod:
+    database_url: "postgres://u:pa$${x}@h/db"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	env, err := cfg.Env("prod")
+	if err != nil {
+		t.Fatalf("Env() error: %v", err)
 	}
-	if !strings.Contains(err.Error(), "unexpanded") {
-		t.Errorf("error should mention unexpanded variable, got: %v", err)
+	if env.DatabaseURL != "postgres://u:pa${x}@h/db" {
+		t.Errorf("database_url = %q, want the escaped ${x} kept literally", env.DatabaseURL)
 	}
 }
 
@@ -116,3 +130,139 @@ func TestValidate_MultipleEnvironments(t *testing.T) {
 		t.Errorf("Validate() returned error for valid multi-env config: %v", err)
 	}
 }
+
+func TestValidate_Hooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Hook
+		wantErr bool
+	}{
+		{"command", Hook{Command: "./pause.sh"}, false},
+		{"sql", Hook{SQL: "hooks/refresh.sql"}, false},
+		{"both", Hook{Command: "./pause.sh", SQL: "hooks/refresh.sql"}, true},
+		{"neither", Hook{}, true},
+	}
+
+	for _, tt := range tests {
+		c := &Config{
+			Environments: map[string]Environment{
+				"prod": {
+					DatabaseURL: "postgres://localhost:5432/prod",
+					Hooks:       Hooks{BeforeUp: []Hook{tt.hook}},
+				},
+			},
+		}
+		err := Validate(c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "hooks.before_up[0]") {
+			t.Errorf("%s: error should name the hook: %v", tt.name, err)
+		}
+	}
+}
+
+func TestValidate_LintSeverities(t *testing.T) {
+	c := &Config{
+		Environments: map[string]Environment{
+			"dev": {DatabaseURL: "postgres://localhost:5432/dev"},
+		},
+		Lint: map[string]string{"drop_table": "fatal"},
+	}
+
+	err := Validate(c)
+	if err == nil || !strings.Contains(err.Error(), "drop_table") {
+		t.Errorf("expected invalid severity error, got: %v", err)
+	}
+}
+
+func TestValidate_PromotionOrder(t *testing.T) {
+	envs := map[string]Environment{
+		"dev":  {DatabaseURL: "postgres://localhost:5432/dev"},
+		"prod": {DatabaseURL: "postgres://localhost:5432/prod"},
+	}
+
+	tests := []struct {
+		name    string
+		order   []string
+		wantErr string
+	}{
+		{"valid", []string{"dev", "prod"}, ""},
+		{"unknown environment", []string{"dev", "staging"}, `unknown environment "staging"`},
+		{"duplicate", []string{"dev", "prod", "dev"}, `"dev" listed twice`},
+	}
+
+	for _, tt := range tests {
+		err := Validate(&Config{Environments: envs, PromotionOrder: tt.order})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got: %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidate_Targets(t *testing.T) {
+	list := []Target{{Name: "acme", DatabaseURL: "postgres://localhost:5432/acme"}}
+
+	tests := []struct {
+		name    string
+		env     Environment
+		wantErr string
+	}{
+		{"list", Environment{Targets: Targets{List: list}}, ""},
+		{"file", Environment{Targets: Targets{File: "tenants.txt"}}, ""},
+		{"query", Environment{Targets: Targets{Query: TargetQuery{DatabaseURL: "postgres://control/db", SQL: "SELECT url FROM tenants"}}}, ""},
+		{"with database_url", Environment{DatabaseURL: "postgres://localhost/db", Targets: Targets{List: list}}, "not both"},
+		{"two sources", Environment{Targets: Targets{List: list, File: "tenants.txt"}}, "exactly one of list, file or query"},
+		{"query without sql", Environment{Targets: Targets{Query: TargetQuery{DatabaseURL: "postgres://control/db"}}}, "needs both"},
+		{"list entry without url", Environment{Targets: Targets{List: []Target{{Name: "acme"}}}}, "targets.list[0].database_url"},
+	}
+
+	for _, tt := range tests {
+		err := Validate(&Config{Environments: map[string]Environment{"tenants": tt.env}})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got: %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidate_Schemas(t *testing.T) {
+	pg := "postgres://localhost:5432/app"
+
+	tests := []struct {
+		name    string
+		env     Environment
+		wantErr string
+	}{
+		{"list", Environment{DatabaseURL: pg, Schemas: Schemas{List: []string{"tenant_a", "tenant_b"}}}, ""},
+		{"pattern", Environment{DatabaseURL: pg, Schemas: Schemas{Pattern: "tenant_*"}}, ""},
+		{"not postgres", Environment{DatabaseURL: "mysql://localhost/app", Schemas: Schemas{Pattern: "tenant_*"}}, "requires a Postgres"},
+		{"list and pattern", Environment{DatabaseURL: pg, Schemas: Schemas{List: []string{"a"}, Pattern: "tenant_*"}}, "not both"},
+		{"bad pattern", Environment{DatabaseURL: pg, Schemas: Schemas{Pattern: "tenant_["}}, "schemas.pattern"},
+		{"duplicate", Environment{DatabaseURL: pg, Schemas: Schemas{List: []string{"a", "a"}}}, `"a" listed twice`},
+	}
+
+	for _, tt := range tests {
+		err := Validate(&Config{Environments: map[string]Environment{"tenants": tt.env}})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got: %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
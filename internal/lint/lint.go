@@ -0,0 +1,283 @@
+// Package lint flags risky operations in migration SQL, such as dropping
+// data or taking long locks, before the migration reaches a database.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cesc1802/janus/internal/schema"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// Rule names, as used in janus.yaml and lint-ignore directives
+const (
+	RuleDropColumn            = "drop_column"
+	RuleDropTable             = "drop_table"
+	RuleAlterColumnType       = "alter_column_type"
+	RuleNotNullWithoutDefault = "not_null_without_default"
+	RuleIndexNotConcurrent    = "index_not_concurrent"
+	RuleTableRewrite          = "table_rewrite"
+	RuleDownMissingIfExists   = "down_missing_if_exists"
+)
+
+// Severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// Rules lists every rule in report order
+var Rules = []string{
+	RuleDropTable,
+	RuleDropColumn,
+	RuleAlterColumnType,
+	RuleNotNullWithoutDefault,
+	RuleIndexNotConcurrent,
+	RuleTableRewrite,
+	RuleDownMissingIfExists,
+}
+
+// Sections of a migration
+const (
+	SectionUp   = "up"
+	SectionDown = "down"
+)
+
+// Finding is one rule violation in a migration
+type Finding struct {
+	Version   uint   `json:"version" yaml:"version"`
+	Name      string `json:"name" yaml:"name"`
+	Rule      string `json:"rule" yaml:"rule"`
+	Severity  string `json:"severity" yaml:"severity"`
+	Section   string `json:"section" yaml:"section"`
+	Message   string `json:"message" yaml:"message"`
+	Statement string `json:"statement" yaml:"statement"`
+}
+
+// Linter checks migrations for one database dialect
+type Linter struct {
+	dialect    string
+	severities map[string]string
+}
+
+// New creates a Linter. dialect enables dialect-specific rules ("postgres",
+// "mysql", "sqlite3" or "" for none). severities overrides the default
+// severity (warning) per rule.
+func New(dialect string, severities map[string]string) *Linter {
+	l := &Linter{dialect: dialect, severities: make(map[string]string, len(Rules))}
+	for _, rule := range Rules {
+		l.severities[rule] = SeverityWarning
+	}
+	for rule, severity := range severities {
+		l.severities[rule] = strings.ToLower(severity)
+	}
+	return l
+}
+
+// ValidateSeverities checks rule names and severity values from config
+func ValidateSeverities(severities map[string]string) error {
+	for rule, severity := range severities {
+		if !isRule(rule) {
+			return fmt.Errorf("unknown lint rule %q (expected one of: %s)", rule, strings.Join(Rules, ", "))
+		}
+		switch strings.ToLower(severity) {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Errorf("lint rule %s: invalid severity %q (expected error, warning or off)", rule, severity)
+		}
+	}
+	return nil
+}
+
+func isRule(name string) bool {
+	for _, rule := range Rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks one migration. Rules that are off or named in the
+// migration's lint-ignore directives are skipped.
+func (l *Linter) Lint(m singlefile.Migration) []Finding {
+	ignored := make(map[string]bool, len(m.LintIgnore))
+	for _, rule := range m.LintIgnore {
+		ignored[rule] = true
+	}
+
+	var findings []Finding
+	report := func(rule, section, message, stmt string) {
+		severity := l.severities[rule]
+		if severity == SeverityOff || ignored[rule] {
+			return
+		}
+		findings = append(findings, Finding{
+			Version:   m.Version,
+			Name:      m.Name,
+			Rule:      rule,
+			Severity:  severity,
+			Section:   section,
+			Message:   message,
+			Statement: stmt,
+		})
+	}
+
+	up := splitStatements(m.Up)
+	created := createdTables(up)
+	for _, stmt := range up {
+		l.checkUp(stmt, created, func(rule, message string) { report(rule, SectionUp, message, stmt) })
+	}
+	for _, stmt := range splitStatements(m.Down) {
+		l.checkDown(stmt, func(rule, message string) { report(rule, SectionDown, message, stmt) })
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return ruleOrder(findings[i].Rule) < ruleOrder(findings[j].Rule)
+	})
+	return findings
+}
+
+func ruleOrder(rule string) int {
+	for i, r := range Rules {
+		if r == rule {
+			return i
+		}
+	}
+	return len(Rules)
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)^CREATE\s+(?:(?:GLOBAL\s+|LOCAL\s+)?(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)`)
+	alterTablePattern  = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([^\s(]+)\s+(.*)$`)
+	dropTablePattern   = regexp.MustCompile(`(?i)^DROP\s+TABLE\b`)
+	createIndexPattern = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?([^\s(]+)`)
+
+	// Patterns for one action of an ALTER TABLE statement
+	dropColumnPattern   = regexp.MustCompile(`(?i)^DROP\s+COLUMN\s+(IF\s+EXISTS\b)?`)
+	alterTypePattern    = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?\S+\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	modifyColumnPattern = regexp.MustCompile(`(?i)^(?:MODIFY|CHANGE)\s`)
+	addColumnPattern    = regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(.*)$`)
+	addConstraintWords  = regexp.MustCompile(`(?i)^(?:CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|INDEX|KEY|CHECK|EXCLUDE|FULLTEXT|SPATIAL)\b`)
+	notNullPattern      = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultPattern      = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	generatedPattern    = regexp.MustCompile(`(?i)\bGENERATED\b.*\bSTORED\b`)
+	volatilePattern     = regexp.MustCompile(`(?i)\bDEFAULT\s+(?:\(\s*)?(?:random|clock_timestamp|gen_random_uuid|uuid_generate_v[14]|uuid|timeofday|nextval)\s*\(`)
+
+	rewritePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^VACUUM\s+(?:\(\s*)?FULL\b`),
+		regexp.MustCompile(`(?i)^CLUSTER\b`),
+		regexp.MustCompile(`(?i)^OPTIMIZE\s+TABLE\b`),
+		regexp.MustCompile(`(?i)^ALTER\s+TABLE\b.*\bSET\s+(?:UN)?LOGGED\b`),
+		regexp.MustCompile(`(?i)^ALTER\s+TABLE\b.*\bALGORITHM\s*=?\s*COPY\b`),
+		regexp.MustCompile(`(?i)^ALTER\s+TABLE\b.*\bENGINE\s*=`),
+		regexp.MustCompile(`(?i)^ALTER\s+TABLE\b.*\bFORCE\s*$`),
+	}
+
+	dropObjectPattern = regexp.MustCompile(`(?i)^DROP\s+(MATERIALIZED\s+VIEW|TABLE|INDEX|VIEW|SEQUENCE|TYPE|FUNCTION|PROCEDURE|TRIGGER|SCHEMA|DOMAIN|EXTENSION)\s+(?:CONCURRENTLY\s+)?(IF\s+EXISTS\b)?`)
+)
+
+// createdTables returns the tables created by the statements, lowercased
+func createdTables(stmts []string) map[string]bool {
+	created := map[string]bool{}
+	for _, stmt := range stmts {
+		if m := createTablePattern.FindStringSubmatch(stmt); m != nil {
+			created[tableKey(m[1])] = true
+		}
+	}
+	return created
+}
+
+func tableKey(name string) string {
+	return strings.ToLower(strings.Trim(name, "\"`"))
+}
+
+func (l *Linter) checkUp(stmt string, created map[string]bool, report func(rule, message string)) {
+	if dropTablePattern.MatchString(stmt) {
+		report(RuleDropTable, "DROP TABLE in UP deletes the table and its data")
+	}
+
+	for _, p := range rewritePatterns {
+		if p.MatchString(stmt) {
+			report(RuleTableRewrite, "rewrites the whole table, blocking writes while it runs")
+			break
+		}
+	}
+
+	if m := createIndexPattern.FindStringSubmatch(stmt); m != nil && l.dialect == schema.DialectPostgres &&
+		m[1] == "" && !created[tableKey(m[2])] {
+		report(RuleIndexNotConcurrent, "CREATE INDEX without CONCURRENTLY blocks writes to the table while the index builds")
+	}
+
+	m := alterTablePattern.FindStringSubmatch(stmt)
+	if m == nil {
+		return
+	}
+	isNew := created[tableKey(m[1])]
+	for _, action := range splitTopLevel(m[2]) {
+		if dropColumnPattern.MatchString(action) {
+			report(RuleDropColumn, "DROP COLUMN deletes the column's data and breaks code still reading it")
+		}
+		if alterTypePattern.MatchString(action) || l.dialect == schema.DialectMySQL && modifyColumnPattern.MatchString(action) {
+			report(RuleAlterColumnType, "changing a column type can rewrite the table and break code using the column")
+		}
+
+		add := addColumnPattern.FindStringSubmatch(action)
+		if add == nil || isNew || addConstraintWords.MatchString(add[1]) {
+			continue
+		}
+		def := add[1]
+		if notNullPattern.MatchString(def) && !defaultPattern.MatchString(def) && !generatedPattern.MatchString(def) {
+			report(RuleNotNullWithoutDefault, "adding a NOT NULL column without a DEFAULT fails on a table that has rows")
+		}
+		if l.dialect == schema.DialectPostgres && (volatilePattern.MatchString(def) || generatedPattern.MatchString(def)) {
+			report(RuleTableRewrite, "adding a column with a volatile default or stored generated value rewrites the table")
+		}
+	}
+}
+
+func (l *Linter) checkDown(stmt string, report func(rule, message string)) {
+	if m := dropObjectPattern.FindStringSubmatch(stmt); m != nil {
+		// MySQL has no DROP INDEX IF EXISTS
+		if m[2] == "" && !(l.dialect == schema.DialectMySQL && strings.EqualFold(m[1], "INDEX")) {
+			report(RuleDownMissingIfExists, "DROP without IF EXISTS fails if UP stopped partway")
+		}
+		return
+	}
+
+	// Only Postgres has DROP COLUMN IF EXISTS
+	m := alterTablePattern.FindStringSubmatch(stmt)
+	if m == nil || l.dialect != schema.DialectPostgres {
+		return
+	}
+	for _, action := range splitTopLevel(m[2]) {
+		if d := dropColumnPattern.FindStringSubmatch(action); d != nil && d[1] == "" {
+			report(RuleDownMissingIfExists, "DROP COLUMN without IF EXISTS fails if UP stopped partway")
+			return
+		}
+	}
+}
+
+// splitTopLevel splits ALTER TABLE actions on commas outside parentheses
+func splitTopLevel(actions string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range actions {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(actions[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(actions[start:]))
+}
@@ -0,0 +1,184 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/schema"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// rules returns the "section:rule" of each finding
+func rules(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Section+":"+f.Rule)
+	}
+	return out
+}
+
+func TestLint_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		up      string
+		down    string
+		want    []string
+	}{
+		{
+			name: "drop table in up",
+			up:   "DROP TABLE legacy;",
+			down: "CREATE TABLE legacy (id INT);",
+			want: []string{"up:drop_table"},
+		},
+		{
+			name: "drop table in down is expected",
+			up:   "CREATE TABLE users (id INT);",
+			down: "DROP TABLE IF EXISTS users;",
+		},
+		{
+			name:    "drop column",
+			dialect: schema.DialectPostgres,
+			up:      "ALTER TABLE users DROP COLUMN legacy, ADD COLUMN note TEXT;",
+			down:    "ALTER TABLE users DROP COLUMN IF EXISTS note;",
+			want:    []string{"up:drop_column"},
+		},
+		{
+			name: "alter column type",
+			up:   "ALTER TABLE orders ALTER COLUMN total TYPE numeric(12,2);",
+			want: []string{"up:alter_column_type"},
+		},
+		{
+			name:    "mysql modify column",
+			dialect: schema.DialectMySQL,
+			up:      "ALTER TABLE orders MODIFY COLUMN total DECIMAL(12,2) NOT NULL DEFAULT 0;",
+			want:    []string{"up:alter_column_type"},
+		},
+		{
+			name: "not null without default",
+			up:   "ALTER TABLE users ADD COLUMN tenant_id BIGINT NOT NULL;",
+			want: []string{"up:not_null_without_default"},
+		},
+		{
+			name: "not null with default",
+			up:   "ALTER TABLE users ADD COLUMN amount NUMERIC(10,2) NOT NULL DEFAULT 0;",
+		},
+		{
+			name: "not null on a table created in the same migration",
+			up:   "CREATE TABLE users (id INT);\nALTER TABLE users ADD COLUMN tenant_id BIGINT NOT NULL;",
+			down: "DROP TABLE IF EXISTS users;",
+		},
+		{
+			name: "add constraint is not a column",
+			up:   "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);",
+		},
+		{
+			name:    "postgres index without concurrently",
+			dialect: schema.DialectPostgres,
+			up:      "CREATE INDEX idx_users_email ON users (email);",
+			down:    "DROP INDEX IF EXISTS idx_users_email;",
+			want:    []string{"up:index_not_concurrent"},
+		},
+		{
+			name:    "postgres concurrent index",
+			dialect: schema.DialectPostgres,
+			up:      "CREATE UNIQUE INDEX CONCURRENTLY idx_users_email ON users (email);",
+		},
+		{
+			name:    "postgres index on new table",
+			dialect: schema.DialectPostgres,
+			up:      "CREATE TABLE users (email TEXT);\nCREATE INDEX idx_users_email ON users (email);",
+		},
+		{
+			name:    "index rule is postgres only",
+			dialect: schema.DialectMySQL,
+			up:      "CREATE INDEX idx_users_email ON users (email);",
+		},
+		{
+			name:    "postgres volatile default rewrites",
+			dialect: schema.DialectPostgres,
+			up:      "ALTER TABLE users ADD COLUMN token UUID NOT NULL DEFAULT gen_random_uuid();",
+			want:    []string{"up:table_rewrite"},
+		},
+		{
+			name: "vacuum full rewrites",
+			up:   "VACUUM FULL users;",
+			want: []string{"up:table_rewrite"},
+		},
+		{
+			name: "down drop without if exists",
+			up:   "CREATE TABLE users (id INT); CREATE VIEW v AS SELECT 1;",
+			down: "DROP VIEW v; DROP TABLE users;",
+			want: []string{"down:down_missing_if_exists", "down:down_missing_if_exists"},
+		},
+		{
+			name:    "down drop column without if exists",
+			dialect: schema.DialectPostgres,
+			up:      "ALTER TABLE users ADD COLUMN note TEXT;",
+			down:    "ALTER TABLE users DROP COLUMN note;",
+			want:    []string{"down:down_missing_if_exists"},
+		},
+		{
+			name:    "sqlite has no drop column if exists",
+			dialect: schema.DialectSQLite,
+			up:      "ALTER TABLE users ADD COLUMN note TEXT;",
+			down:    "ALTER TABLE users DROP COLUMN note;",
+		},
+		{
+			name:    "mysql has no drop index or column if exists",
+			dialect: schema.DialectMySQL,
+			up:      "ALTER TABLE users ADD COLUMN note TEXT;",
+			down:    "DROP INDEX idx ON users; ALTER TABLE users DROP COLUMN note;",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := singlefile.Migration{Version: 1, Name: "test", Up: tc.up, Down: tc.down}
+			got := rules(New(tc.dialect, nil).Lint(m))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Lint() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLint_Severities(t *testing.T) {
+	m := singlefile.Migration{
+		Version: 3,
+		Name:    "drop_legacy",
+		Up:      "DROP TABLE legacy; ALTER TABLE users DROP COLUMN old;",
+	}
+
+	findings := New("", map[string]string{RuleDropTable: "ERROR", RuleDropColumn: SeverityOff}).Lint(m)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	f := findings[0]
+	if f.Rule != RuleDropTable || f.Severity != SeverityError || f.Version != 3 || f.Statement != "DROP TABLE legacy" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestLint_IgnoreDirective(t *testing.T) {
+	m := singlefile.Migration{
+		Up:         "DROP TABLE legacy; ALTER TABLE users DROP COLUMN old;",
+		LintIgnore: []string{RuleDropTable},
+	}
+
+	if got := rules(New("", nil).Lint(m)); !reflect.DeepEqual(got, []string{"up:drop_column"}) {
+		t.Errorf("Lint() = %v, want only drop_column", got)
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	if err := ValidateSeverities(map[string]string{RuleDropTable: "error", RuleTableRewrite: "Off"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateSeverities(map[string]string{"drop_everything": "error"}); err == nil {
+		t.Error("expected error for unknown rule")
+	}
+	if err := ValidateSeverities(map[string]string{RuleDropTable: "fatal"}); err == nil {
+		t.Error("expected error for invalid severity")
+	}
+}
@@ -0,0 +1,98 @@
+package lint
+
+import "strings"
+
+// splitStatements splits SQL into statements on top-level semicolons.
+// Comments are dropped and runs of whitespace collapsed to one space.
+// Semicolons inside quoted strings, quoted identifiers and dollar-quoted
+// bodies do not end a statement.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var b strings.Builder
+
+	flush := func() {
+		if s := strings.Join(strings.Fields(b.String()), " "); s != "" {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+			}
+			b.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			end := closingQuote(sql, i+1, c)
+			b.WriteString(sql[i:end])
+			i = end - 1
+		case c == '$':
+			if tag, ok := dollarTag(sql[i:]); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					end = len(sql)
+				} else {
+					end = i + len(tag) + end + len(tag)
+				}
+				b.WriteString(sql[i:end])
+				i = end - 1
+				continue
+			}
+			b.WriteByte(c)
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// closingQuote returns the index just past the quote closing a literal that
+// starts at from; a doubled quote is an escaped quote
+func closingQuote(sql string, from int, quote byte) int {
+	for i := from; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote == '\'' {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag reports the $tag$ that opens a Postgres dollar-quoted string
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1], true
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
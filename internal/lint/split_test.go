@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "simple",
+			sql:  "CREATE TABLE a (id INT);\nDROP TABLE b;",
+			want: []string{"CREATE TABLE a (id INT)", "DROP TABLE b"},
+		},
+		{
+			name: "comments dropped",
+			sql:  "-- drop it; really\nDROP TABLE a; /* ; */ DROP TABLE b",
+			want: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "quoted semicolons",
+			sql:  "INSERT INTO t VALUES ('a;b', 'it''s'); SELECT \"x;y\" FROM t;",
+			want: []string{"INSERT INTO t VALUES ('a;b', 'it''s')", "SELECT \"x;y\" FROM t"},
+		},
+		{
+			name: "dollar quoted body",
+			sql:  "CREATE FUNCTION f() RETURNS void AS $body$ BEGIN DROP TABLE x; END $body$ LANGUAGE plpgsql; SELECT $1;",
+			want: []string{"CREATE FUNCTION f() RETURNS void AS $body$ BEGIN DROP TABLE x; END $body$ LANGUAGE plpgsql", "SELECT $1"},
+		},
+		{
+			name: "whitespace collapsed",
+			sql:  "ALTER TABLE t\n    ADD COLUMN x INT;\n\n",
+			want: []string{"ALTER TABLE t ADD COLUMN x INT"},
+		},
+		{
+			name: "empty",
+			sql:  "  -- nothing\n;;",
+			want: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := splitStatements(tc.sql); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package migrator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	nurl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openDB opens a plain database/sql handle for a migrate-style database URL,
+// using the drivers registered by golang-migrate. Custom "x-" query
+// parameters understood only by golang-migrate are removed.
+func openDB(url string) (*sql.DB, string, error) {
+	dialect := databaseDialect(url)
+	var driverName, dsn string
+
+	switch dialect {
+	case DialectPostgres:
+		driverName, dsn = "postgres", filterCustomParams(url)
+	case DialectMySQL:
+		var err error
+		if dsn, err = mysqlDSN(url); err != nil {
+			return nil, "", err
+		}
+		driverName = "mysql"
+	case DialectSQLite:
+		driverName, dsn = "sqlite3", filterCustomParams(stripScheme(url))
+	default:
+		return nil, "", fmt.Errorf("unsupported database URL scheme")
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("open database: %w", err)
+	}
+	return db, dialect, nil
+}
+
+// mysqlDSN turns a mysql:// URL into a go-sql-driver DSN the way
+// golang-migrate's mysql driver reads it: the TLS config named by a custom
+// tls parameter is registered from the x-tls-* parameters, and the user
+// and password are unescaped.
+func mysqlDSN(url string) (string, error) {
+	if err := registerMySQLTLS(url); err != nil {
+		return "", fmt.Errorf("mysql tls: %w", err)
+	}
+	cfg, err := mysql.ParseDSN(filterCustomParams(stripScheme(url)))
+	if err != nil {
+		return "", fmt.Errorf("parse mysql url: %w", err)
+	}
+	if cfg.User, err = nurl.QueryUnescape(cfg.User); err != nil {
+		return "", fmt.Errorf("parse mysql url: user: %w", err)
+	}
+	if cfg.Passwd, err = nurl.QueryUnescape(cfg.Passwd); err != nil {
+		return "", fmt.Errorf("parse mysql url: password: %w", err)
+	}
+	// Hook SQL files hold several statements, as migrations do
+	cfg.MultiStatements = true
+	return cfg.FormatDSN(), nil
+}
+
+// registerMySQLTLS registers the TLS config a mysql:// URL names in its tls
+// parameter, built from x-tls-ca, x-tls-cert, x-tls-key and
+// x-tls-insecure-skip-verify. Does nothing for tls=true, false or
+// skip-verify, or without x-tls-ca.
+func registerMySQLTLS(url string) error {
+	name, ca := urlParam(url, "tls"), urlParam(url, "x-tls-ca")
+	if ca == "" {
+		return nil
+	}
+	if _, err := strconv.ParseBool(name); err == nil || name == "" || strings.EqualFold(name, "skip-verify") {
+		return nil
+	}
+
+	pem, err := os.ReadFile(ca)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates in %s", ca)
+	}
+	config := &tls.Config{RootCAs: roots}
+
+	cert, key := urlParam(url, "x-tls-cert"), urlParam(url, "x-tls-key")
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return errors.New("x-tls-cert and x-tls-key must be set together")
+		}
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	if skip := urlParam(url, "x-tls-insecure-skip-verify"); skip != "" {
+		if config.InsecureSkipVerify, err = strconv.ParseBool(skip); err != nil {
+			return fmt.Errorf("x-tls-insecure-skip-verify: %w", err)
+		}
+	}
+	return mysql.RegisterTLSConfig(name, config)
+}
+
+// stripScheme removes the "scheme://" prefix from a database URL
+func stripScheme(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		return rest
+	}
+	return url
+}
+
+// filterCustomParams removes golang-migrate "x-" query parameters.
+// The query is split off manually since MySQL DSNs are not valid URLs.
+func filterCustomParams(dsn string) string {
+	base, rawQuery, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn
+	}
+	var kept []string
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if kv == "" || strings.HasPrefix(kv, "x-") {
+			continue
+		}
+		kept = append(kept, kv)
+	}
+	if len(kept) == 0 {
+		return base
+	}
+	return base + "?" + strings.Join(kept, "&")
+}
+
+// urlParam returns a query parameter of a database URL ("" if absent)
+func urlParam(url, name string) string {
+	_, rawQuery, ok := strings.Cut(url, "?")
+	if !ok {
+		return ""
+	}
+	values, err := nurl.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	return values.Get(name)
+}
+
+// migrationsTable returns the version table name for a database URL
+func migrationsTable(url string) string {
+	if table := urlParam(url, "x-migrations-table"); table != "" {
+		return table
+	}
+	return defaultMigrationsTable
+}
+
+// urlPath returns the path component of a database URL, as golang-migrate
+// uses it for the Postgres database name
+func urlPath(url string) string {
+	purl, err := nurl.Parse(url)
+	if err != nil {
+		return ""
+	}
+	return purl.Path
+}
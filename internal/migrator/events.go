@@ -0,0 +1,162 @@
+package migrator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType identifies a migration lifecycle event
+type EventType string
+
+// Migration lifecycle events
+const (
+	EventStart  EventType = "start"
+	EventFinish EventType = "finish"
+	EventFail   EventType = "fail"
+)
+
+// Event describes one step of a migration run.
+// The JSON form is a stable, documented schema (see docs/cli-reference.md).
+type Event struct {
+	Type        EventType     `json:"event"`
+	Environment string        `json:"env"`
+	Schema      string        `json:"schema,omitempty"`
+	Version     uint          `json:"version"`
+	Name        string        `json:"name"`
+	Direction   string        `json:"direction"`
+	Time        time.Time     `json:"time"`
+	Duration    time.Duration `json:"-"`
+	DurationMS  int64         `json:"duration_ms"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// EventHandler receives migration events. Calls are serialized.
+type EventHandler func(Event)
+
+// OnEvent registers h to receive start, finish and fail events for every
+// migration run by this migrator. Replaces any previous handler.
+func (mg *Migrator) OnEvent(h EventHandler) {
+	if mg.schemas != nil {
+		// Children run one at a time, so calls stay serialized
+		for _, child := range mg.schemas {
+			child.OnEvent(h)
+		}
+		return
+	}
+	mg.events = &eventLogger{
+		envName: mg.envName,
+		schema:  mg.schema,
+		handler: h,
+		names: func(version uint) string {
+			return mg.migrations()[version].Name
+		},
+	}
+	mg.m.Log = mg.events
+}
+
+// logLinePattern matches golang-migrate's per-migration log lines, e.g.
+// "Read and execute 3/u add_orders" and "Finished 3/u add_orders (read 1ms, ran 2ms)"
+var logLinePattern = regexp.MustCompile(`^(Read and execute|Finished) (\d+)/([ud]) (.+?)(?: \(read .*\))?$`)
+
+// eventLogger implements migrate.Logger and turns log lines into events
+type eventLogger struct {
+	envName string
+	schema  string
+	handler EventHandler
+	// names resolves a version to its file name; golang-migrate reports
+	// migrations without a body as "<empty>"
+	names func(uint) string
+
+	mu      sync.Mutex
+	current *Event // started but not yet finished
+}
+
+// Printf receives golang-migrate log output
+func (l *eventLogger) Printf(format string, v ...interface{}) {
+	line := strings.TrimRight(fmt.Sprintf(format, v...), "\r\n")
+	match := logLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	version, err := strconv.ParseUint(match[2], 10, 64)
+	if err != nil {
+		return
+	}
+	direction := DirectionUp
+	if match[3] == "d" {
+		direction = DirectionDown
+	}
+	name := match[4]
+	if l.names != nil {
+		if n := l.names(uint(version)); n != "" {
+			name = n
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	switch match[1] {
+	case "Read and execute":
+		l.current = &Event{
+			Environment: l.envName,
+			Schema:      l.schema,
+			Version:     uint(version),
+			Name:        name,
+			Direction:   direction,
+			Time:        now,
+		}
+		l.emit(EventStart, *l.current, now, "")
+	case "Finished":
+		started := Event{Environment: l.envName, Schema: l.schema, Version: uint(version), Name: name, Direction: direction, Time: now}
+		if l.current != nil && l.current.Version == uint(version) {
+			started = *l.current
+		} else {
+			// Migrations without a body are never "executed"; report them anyway
+			l.emit(EventStart, started, now, "")
+		}
+		l.current = nil
+		l.emit(EventFinish, started, now, "")
+	}
+}
+
+// Verbose enables the per-migration "Read and execute" lines
+func (l *eventLogger) Verbose() bool {
+	return true
+}
+
+// fail reports the in-flight migration, if any, as failed
+func (l *eventLogger) fail(err error) {
+	if err == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return
+	}
+	started := *l.current
+	l.current = nil
+	l.emit(EventFail, started, time.Now(), err.Error())
+}
+
+// emit sends an event derived from started to the handler (mu must be held)
+func (l *eventLogger) emit(t EventType, started Event, now time.Time, errMsg string) {
+	e := started
+	e.Type = t
+	e.Error = errMsg
+	if t != EventStart {
+		e.Time = now
+		e.Duration = now.Sub(started.Time)
+		e.DurationMS = e.Duration.Milliseconds()
+	}
+	l.handler(e)
+}
@@ -0,0 +1,103 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func collectEvents(l *eventLogger) *[]Event {
+	var events []Event
+	l.handler = func(e Event) { events = append(events, e) }
+	return &events
+}
+
+func TestEventLogger_StartFinish(t *testing.T) {
+	l := &eventLogger{envName: "dev"}
+	events := collectEvents(l)
+
+	l.Printf("Start buffering %v\n", "3/u add_orders")
+	l.Printf("Read and execute %v\n", "3/u add_orders")
+	l.Printf("Finished %v (read %v, ran %v)\n", "3/u add_orders", "1ms", "2ms")
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(*events), *events)
+	}
+	start, finish := (*events)[0], (*events)[1]
+	if start.Type != EventStart || start.Version != 3 || start.Name != "add_orders" || start.Direction != DirectionUp {
+		t.Errorf("unexpected start event: %+v", start)
+	}
+	if finish.Type != EventFinish || finish.Environment != "dev" {
+		t.Errorf("unexpected finish event: %+v", finish)
+	}
+}
+
+func TestEventLogger_Down(t *testing.T) {
+	l := &eventLogger{}
+	events := collectEvents(l)
+
+	l.Printf("Read and execute %v\n", "2/d add_index")
+
+	if len(*events) != 1 || (*events)[0].Direction != DirectionDown || (*events)[0].Version != 2 {
+		t.Errorf("unexpected events: %+v", *events)
+	}
+}
+
+func TestEventLogger_Fail(t *testing.T) {
+	l := &eventLogger{}
+	events := collectEvents(l)
+
+	l.fail(errors.New("ignored: nothing in flight"))
+	l.Printf("Read and execute %v\n", "4/u broken")
+	l.fail(errors.New("syntax error"))
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(*events), *events)
+	}
+	fail := (*events)[1]
+	if fail.Type != EventFail || fail.Version != 4 || fail.Error != "syntax error" {
+		t.Errorf("unexpected fail event: %+v", fail)
+	}
+}
+
+func TestEventLogger_FinishWithoutStart(t *testing.T) {
+	l := &eventLogger{}
+	events := collectEvents(l)
+
+	l.Printf("Finished %v (read %v, ran %v)\n", "5/u empty", "0s", "1ms")
+
+	if len(*events) != 2 || (*events)[0].Type != EventStart || (*events)[1].Type != EventFinish {
+		t.Errorf("expected synthesized start and finish: %+v", *events)
+	}
+}
+
+func TestOnEvent_Run(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);",
+		"000002_b.sql": "-- +migrate UP\nCREATE TABLE broken (;",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+
+	var events []Event
+	mg.OnEvent(func(e Event) { events = append(events, e) })
+
+	if err := mg.Up(0); err == nil {
+		t.Fatal("expected migration 2 to fail")
+	}
+
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []EventType{EventStart, EventFinish, EventStart, EventFail}
+	if len(types) != len(want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("event types = %v, want %v", types, want)
+		}
+	}
+	if events[3].Version != 2 || events[3].Error == "" {
+		t.Errorf("unexpected fail event: %+v", events[3])
+	}
+}
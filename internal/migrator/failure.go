@@ -0,0 +1,134 @@
+package migrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// FailureDir is where janus records the last failed migration per
+// environment, relative to the config file's directory unless absolute.
+// golang-migrate only keeps a dirty flag, so the error itself is only
+// available to runs using the same config file as the failing one.
+var FailureDir = filepath.Join(".janus", "failures")
+
+// configDir returns the directory FailureDir is relative to for cfg
+func configDir(cfg *config.Config) string {
+	if cfg.File == "" {
+		return ""
+	}
+	return filepath.Dir(cfg.File)
+}
+
+// useConfigDir keeps the failure records of mg and its schemas next to the
+// config file of cfg
+func (mg *Migrator) useConfigDir(cfg *config.Config) {
+	mg.configDir = configDir(cfg)
+	for _, child := range mg.schemas {
+		child.configDir = mg.configDir
+	}
+}
+
+// failureDir returns FailureDir resolved against the config directory
+func (mg *Migrator) failureDir() string {
+	if filepath.IsAbs(FailureDir) {
+		return FailureDir
+	}
+	return filepath.Join(mg.configDir, FailureDir)
+}
+
+// Failure records a migration that failed and left the database dirty
+type Failure struct {
+	Environment string    `json:"env"`
+	Schema      string    `json:"schema,omitempty"`
+	Version     uint      `json:"version"`
+	Name        string    `json:"name"`
+	Direction   string    `json:"direction"`
+	Error       string    `json:"error"`
+	Time        time.Time `json:"time"`
+}
+
+// recordFailure saves which migration a failed run stopped in.
+// Best effort: the run's own error is what matters to the caller.
+func (mg *Migrator) recordFailure(before uint, runErr error) {
+	if mg.shadow || runErr == nil || errors.Is(runErr, migrate.ErrNoChange) {
+		return
+	}
+	version, dirty, err := mg.m.Version()
+	if err != nil || !dirty {
+		return
+	}
+
+	// golang-migrate marks the target version dirty before running a body:
+	// the migrated-to version for UP, the one below the migration for DOWN
+	f := Failure{
+		Environment: mg.envName,
+		Schema:      mg.schema,
+		Version:     version,
+		Direction:   DirectionUp,
+		Error:       runErr.Error(),
+		Time:        time.Now().UTC(),
+	}
+	if version < before {
+		f.Direction = DirectionDown
+		if next, err := mg.sourceDriver.Next(version); err == nil {
+			f.Version = next
+		}
+	}
+	f.Name = mg.migrations()[f.Version].Name
+
+	_ = writeFailure(mg.failureDir(), &f)
+}
+
+// LastFailure returns the recorded failure for the environment (nil if none)
+func (mg *Migrator) LastFailure() (*Failure, error) {
+	data, err := os.ReadFile(failurePath(mg.failureDir(), mg.envName, mg.schema))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read failure record: %w", err)
+	}
+
+	var f Failure
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse failure record: %w", err)
+	}
+	return &f, nil
+}
+
+// clearFailure removes the recorded failure for the environment
+func (mg *Migrator) clearFailure() error {
+	err := os.Remove(failurePath(mg.failureDir(), mg.envName, mg.schema))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove failure record: %w", err)
+	}
+	return nil
+}
+
+func writeFailure(dir string, f *Failure) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(failurePath(dir, f.Environment, f.Schema), data, 0600)
+}
+
+// failurePath returns the failure record in dir of an environment, or of
+// one of its schemas
+func failurePath(dir, envName, schemaName string) string {
+	if schemaName != "" {
+		envName += "." + schemaName
+	}
+	return filepath.Join(dir, envName+".json")
+}
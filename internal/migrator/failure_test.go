@@ -0,0 +1,97 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+func TestLastFailure_None(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	f, err := mg.LastFailure()
+	if err != nil || f != nil {
+		t.Errorf("LastFailure() = %+v, %v; want nil, nil", f, err)
+	}
+}
+
+func TestFailureRecord_RoundTrip(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	want := Failure{Environment: "test", Version: 3, Name: "c", Direction: DirectionDown, Error: "boom", Time: time.Now().UTC()}
+	if err := writeFailure(mg.failureDir(), &want); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(failurePath("dir", "test", "")) != "test.json" {
+		t.Errorf("unexpected failure path: %s", failurePath("dir", "test", ""))
+	}
+	if filepath.Base(failurePath("dir", "test", "tenant_a")) != "test.tenant_a.json" {
+		t.Errorf("unexpected schema failure path: %s", failurePath("dir", "test", "tenant_a"))
+	}
+
+	got, err := mg.LastFailure()
+	if err != nil {
+		t.Fatalf("LastFailure() error: %v", err)
+	}
+	if got == nil || got.Version != 3 || got.Direction != DirectionDown || got.Error != "boom" {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+
+	if err := mg.clearFailure(); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := mg.LastFailure(); f != nil {
+		t.Error("failure should be cleared")
+	}
+}
+
+func TestRecordFailure_CleanRunNotRecorded(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if f, _ := mg.LastFailure(); f != nil {
+		t.Errorf("successful run should not record a failure: %+v", f)
+	}
+}
+
+func TestRecordFailure_NextToConfig(t *testing.T) {
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(migrationsDir, "000001_a.sql"), []byte("-- +migrate UP\nCREATE TABL a (id INT);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "janus.yaml")
+	if err := os.WriteFile(path, []byte("environments:\n  test:\n    database_url: sqlite3://test.db\n    migrations_path: ./migrations\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadLayered(path, config.LoadOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run from another directory
+	t.Chdir(t.TempDir())
+	mg, err := New(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mg.Close() }()
+	if err := mg.Up(0); err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".janus", "failures", "test.json")); err != nil {
+		t.Errorf("failure not recorded next to the config: %v", err)
+	}
+	if f, _ := mg.LastFailure(); f == nil || f.Version != 1 {
+		t.Errorf("LastFailure() = %+v", f)
+	}
+}
@@ -0,0 +1,414 @@
+package migrator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// ErrFleet is returned by New for an environment that defines targets
+// instead of a single database_url
+var ErrFleet = errors.New("only 'janus up' runs against a fleet")
+
+// Target outcomes in a rollout
+const (
+	TargetMigrated = "migrated"
+	TargetCurrent  = "up-to-date"
+	TargetFailed   = "failed"
+	TargetStopped  = "stopped"
+	TargetSkipped  = "skipped"
+)
+
+// TargetResult is the outcome of migrating one target of a fleet
+type TargetResult struct {
+	Target   string        `json:"target" yaml:"target"`
+	Canary   bool          `json:"canary" yaml:"canary"`
+	Outcome  string        `json:"outcome" yaml:"outcome"`
+	From     uint          `json:"from" yaml:"from"`
+	To       uint          `json:"to" yaml:"to"`
+	Applied  int           `json:"applied" yaml:"applied"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RolloutOptions controls how up is rolled out to a fleet
+type RolloutOptions struct {
+	// Steps is passed to Up for each target (0 = all pending)
+	Steps int
+	// Canary targets are migrated first; any failure among them stops the
+	// rollout
+	Canary int
+	// Parallelism is how many of the remaining targets run at once
+	Parallelism int
+	// MaxFailures is how many targets may fail before no new target starts
+	MaxFailures int
+}
+
+// Rollout migrates every target of a fleet environment
+type Rollout struct {
+	envName string
+	env     config.Environment
+	targets []config.Target
+	opts    RolloutOptions
+	// configDir is the config file's directory, which FailureDir is
+	// relative to
+	configDir string
+
+	mu      sync.Mutex
+	running map[string]*Migrator
+	stopped bool
+}
+
+// NewRollout resolves the targets of a fleet environment. Options left at
+// zero are not filled in; use FleetOptions for the configured values.
+func NewRollout(cfg *config.Config, envName string, opts RolloutOptions) (*Rollout, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+	if !env.Targets.IsSet() {
+		return nil, fmt.Errorf("environment %q has no targets", envName)
+	}
+	targets, err := resolveTargets(env.Targets)
+	if err != nil {
+		return nil, fmt.Errorf("environment %q: targets: %w", envName, err)
+	}
+	if opts.Parallelism < 1 {
+		opts.Parallelism = 1
+	}
+	return &Rollout{
+		envName:   envName,
+		env:       env,
+		targets:   targets,
+		opts:      opts,
+		configDir: configDir(cfg),
+		running:   map[string]*Migrator{},
+	}, nil
+}
+
+// FleetOptions returns the rollout options configured for an environment
+func FleetOptions(cfg *config.Config, envName string) (RolloutOptions, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return RolloutOptions{}, err
+	}
+	return RolloutOptions{
+		Canary:      env.Targets.CanaryCount(),
+		Parallelism: env.Targets.Parallelism,
+		MaxFailures: env.Targets.MaxFailures,
+	}, nil
+}
+
+// IsFleet reports whether an environment defines targets
+func IsFleet(cfg *config.Config, envName string) (bool, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return false, err
+	}
+	return env.Targets.IsSet(), nil
+}
+
+// Targets returns the resolved targets in rollout order
+func (r *Rollout) Targets() []config.Target {
+	return r.targets
+}
+
+// Options returns the options the rollout runs with
+func (r *Rollout) Options() RolloutOptions {
+	return r.opts
+}
+
+// RequiresConfirmation returns whether the environment needs confirmation
+func (r *Rollout) RequiresConfirmation() bool {
+	return r.env.RequireConfirmation
+}
+
+// Stop asks the rollout to start no new target and stops each running
+// target after its current migration. Safe to call from any goroutine.
+func (r *Rollout) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	for _, mg := range r.running {
+		mg.GracefulStop()
+	}
+}
+
+// Stopped returns whether Stop was called
+func (r *Rollout) Stopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+// Run migrates the canary targets one at a time, then the rest with the
+// configured parallelism. A failing canary, or more than MaxFailures failed
+// targets, stops the rollout: targets not yet started are skipped. onResult,
+// if set, is called as each target finishes. Results follow target order.
+// The error reports failed targets, or why the rollout stopped.
+func (r *Rollout) Run(onResult func(TargetResult)) ([]TargetResult, error) {
+	results := make([]TargetResult, len(r.targets))
+	var mu sync.Mutex
+	failed := 0
+	halted := ""
+
+	finish := func(i int, res TargetResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = res
+		if res.Outcome == TargetFailed {
+			failed++
+			if res.Canary && halted == "" {
+				halted = fmt.Sprintf("canary %s failed", res.Target)
+			} else if failed > r.opts.MaxFailures && halted == "" {
+				halted = fmt.Sprintf("%d target(s) failed, more than max_failures (%d)", failed, r.opts.MaxFailures)
+			}
+		}
+		if onResult != nil {
+			onResult(res)
+		}
+	}
+	proceed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return halted == "" && !r.Stopped()
+	}
+
+	canary := min(r.opts.Canary, len(r.targets))
+	for i := 0; i < canary; i++ {
+		if !proceed() {
+			break
+		}
+		res := r.migrate(r.targets[i])
+		res.Canary = true
+		finish(i, res)
+	}
+
+	sem := make(chan struct{}, r.opts.Parallelism)
+	var wg sync.WaitGroup
+	for i := canary; i < len(r.targets); i++ {
+		sem <- struct{}{}
+		if !proceed() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			finish(i, r.migrate(r.targets[i]))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if res.Outcome == "" {
+			results[i] = TargetResult{Target: r.targets[i].Name, Canary: i < canary, Outcome: TargetSkipped}
+		}
+	}
+
+	switch {
+	case halted != "":
+		return results, fmt.Errorf("rollout stopped: %s", halted)
+	case failed > 0:
+		return results, fmt.Errorf("%d target(s) failed", failed)
+	}
+	return results, nil
+}
+
+// migrate runs up against one target
+func (r *Rollout) migrate(t config.Target) TargetResult {
+	res := TargetResult{Target: t.Name}
+	start := time.Now()
+	fail := func(err error) TargetResult {
+		res.Outcome = TargetFailed
+		res.Error = err.Error()
+		res.Duration = time.Since(start)
+		return res
+	}
+
+	env := r.env
+	env.DatabaseURL = t.DatabaseURL
+	env.Targets = config.Targets{}
+	mg, err := newMigrator(r.envName, env)
+	if err != nil {
+		return fail(err)
+	}
+	defer func() { _ = mg.Close() }()
+	mg.configDir = r.configDir
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		res.Outcome = TargetSkipped
+		return res
+	}
+	r.running[t.Name] = mg
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, t.Name)
+		r.mu.Unlock()
+	}()
+
+	before, err := mg.Status()
+	if err != nil {
+		return fail(fmt.Errorf("get status: %w", err))
+	}
+	res.From, res.To = before.Version, before.Version
+
+	upErr := mg.Up(r.opts.Steps)
+	if upErr == migrate.ErrNoChange {
+		upErr = nil
+	}
+	after, err := mg.Status()
+	if err == nil {
+		res.To = after.Version
+		res.Applied = after.Applied - before.Applied
+	}
+	if upErr != nil {
+		return fail(upErr)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("get status: %w", err))
+	}
+
+	switch {
+	case mg.Stopped() && after.Pending > 0:
+		res.Outcome = TargetStopped
+	case res.Applied == 0:
+		res.Outcome = TargetCurrent
+	default:
+		res.Outcome = TargetMigrated
+	}
+	res.Duration = time.Since(start)
+	return res
+}
+
+// resolveTargets reads the targets from their configured source and checks
+// that names are unique
+func resolveTargets(t config.Targets) ([]config.Target, error) {
+	var targets []config.Target
+	var err error
+	switch {
+	case len(t.List) > 0:
+		targets = append(targets, t.List...)
+	case t.File != "":
+		targets, err = targetsFromFile(t.File)
+	default:
+		targets, err = targetsFromQuery(t.Query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no targets found")
+	}
+
+	seen := make(map[string]bool, len(targets))
+	for i, target := range targets {
+		if target.Name == "" {
+			targets[i].Name = targetName(target.DatabaseURL)
+		}
+		if seen[targets[i].Name] {
+			return nil, fmt.Errorf("target %q listed twice", targets[i].Name)
+		}
+		seen[targets[i].Name] = true
+	}
+	return targets, nil
+}
+
+// targetsFromFile parses a targets file: one "[name] database_url" per line
+func targetsFromFile(file string) ([]config.Target, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var targets []config.Target
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"[name] database_url\"", file, n)
+		}
+		url, err := config.Expand(fields[len(fields)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		target := config.Target{DatabaseURL: url}
+		if len(fields) == 2 {
+			target.Name = fields[0]
+		}
+		targets = append(targets, target)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	return targets, nil
+}
+
+// targetsFromQuery reads targets from a control database
+func targetsFromQuery(q config.TargetQuery) ([]config.Target, error) {
+	db, _, err := openDB(q.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("control database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	rows, err := db.Query(q.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	if len(cols) != 1 && len(cols) != 2 {
+		return nil, fmt.Errorf("query must return 1 column (database_url) or 2 (name, database_url), got %d", len(cols))
+	}
+
+	var targets []config.Target
+	for rows.Next() {
+		var t config.Target
+		if len(cols) == 1 {
+			err = rows.Scan(&t.DatabaseURL)
+		} else {
+			err = rows.Scan(&t.Name, &t.DatabaseURL)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return targets, nil
+}
+
+// targetName names a target after the last element of its URL path: the
+// database name, or the SQLite file name
+func targetName(url string) string {
+	if p := urlPath(url); p != "" {
+		return path.Base(p)
+	}
+	name, _, _ := strings.Cut(path.Base(stripScheme(url)), "?")
+	return name
+}
@@ -0,0 +1,235 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// setupFleet configures a "tenants" environment whose targets are SQLite
+// databases in a temp dir; a target named "bad" points to a missing dir
+func setupFleet(t *testing.T, names []string, rollout map[string]interface{}) *config.Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	for name, content := range testMigrationFiles {
+		_ = os.WriteFile(filepath.Join(migrationsDir, name), []byte(content), 0644)
+	}
+
+	var list []interface{}
+	for _, name := range names {
+		url := "sqlite3://" + filepath.Join(dir, name+".db")
+		if name == "bad" {
+			url = "sqlite3://" + filepath.Join(dir, "missing", "bad.db")
+		}
+		list = append(list, map[string]interface{}{"name": name, "database_url": url})
+	}
+	targets := map[string]interface{}{"list": list}
+	for k, v := range rollout {
+		targets[k] = v
+	}
+
+	return testConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"tenants": map[string]interface{}{
+				"migrations_path": migrationsDir,
+				"targets":         targets,
+			},
+		},
+	})
+}
+
+func runRollout(t *testing.T, cfg *config.Config) ([]TargetResult, error) {
+	t.Helper()
+
+	opts, err := FleetOptions(cfg, "tenants")
+	if err != nil {
+		t.Fatalf("FleetOptions() error: %v", err)
+	}
+	r, err := NewRollout(cfg, "tenants", opts)
+	if err != nil {
+		t.Fatalf("NewRollout() error: %v", err)
+	}
+	return r.Run(nil)
+}
+
+func outcomes(results []TargetResult) string {
+	var parts []string
+	for _, r := range results {
+		parts = append(parts, r.Target+"="+r.Outcome)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestRollout_MigratesAllTargets(t *testing.T) {
+	cfg := setupFleet(t, []string{"acme", "globex", "initech"}, map[string]interface{}{"parallelism": 2})
+
+	results, err := runRollout(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := outcomes(results); got != "acme=migrated,globex=migrated,initech=migrated" {
+		t.Errorf("unexpected outcomes: %s", got)
+	}
+	if !results[0].Canary || results[1].Canary || results[1].To != 3 || results[1].Applied != 3 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	results, err = runRollout(t, cfg)
+	if err != nil {
+		t.Fatalf("second Run() error: %v", err)
+	}
+	if got := outcomes(results); got != "acme=up-to-date,globex=up-to-date,initech=up-to-date" {
+		t.Errorf("unexpected outcomes on rerun: %s", got)
+	}
+}
+
+func TestRollout_CanaryFailureStops(t *testing.T) {
+	cfg := setupFleet(t, []string{"bad", "acme", "globex"}, nil)
+
+	results, err := runRollout(t, cfg)
+	if err == nil || !strings.Contains(err.Error(), "canary bad failed") {
+		t.Fatalf("expected canary failure, got: %v", err)
+	}
+	if got := outcomes(results); got != "bad=failed,acme=skipped,globex=skipped" {
+		t.Errorf("unexpected outcomes: %s", got)
+	}
+	if results[0].Error == "" {
+		t.Error("failed target should carry its error")
+	}
+}
+
+func TestRollout_MaxFailures(t *testing.T) {
+	names := []string{"acme", "bad", "globex", "initech"}
+
+	cfg := setupFleet(t, names, map[string]interface{}{"parallelism": 1})
+	results, err := runRollout(t, cfg)
+	if err == nil || !strings.Contains(err.Error(), "rollout stopped") {
+		t.Fatalf("expected rollout to stop, got: %v", err)
+	}
+	if got := outcomes(results); got != "acme=migrated,bad=failed,globex=skipped,initech=skipped" {
+		t.Errorf("unexpected outcomes: %s", got)
+	}
+
+	cfg = setupFleet(t, names, map[string]interface{}{"parallelism": 1, "max_failures": 1})
+	results, err = runRollout(t, cfg)
+	if err == nil || err.Error() != "1 target(s) failed" {
+		t.Fatalf("expected failure count, got: %v", err)
+	}
+	if got := outcomes(results); got != "acme=migrated,bad=failed,globex=migrated,initech=migrated" {
+		t.Errorf("unexpected outcomes: %s", got)
+	}
+}
+
+func TestRollout_NoCanary(t *testing.T) {
+	cfg := setupFleet(t, []string{"acme", "globex"}, map[string]interface{}{"canary": 0})
+
+	results, err := runRollout(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	for _, r := range results {
+		if r.Canary {
+			t.Errorf("%s should not be a canary", r.Target)
+		}
+	}
+}
+
+func TestNew_FleetEnvironment(t *testing.T) {
+	cfg := setupFleet(t, []string{"acme"}, nil)
+
+	if _, err := New(cfg, "tenants"); !errors.Is(err, ErrFleet) {
+		t.Errorf("expected ErrFleet, got: %v", err)
+	}
+	if fleet, err := IsFleet(cfg, "tenants"); err != nil || !fleet {
+		t.Errorf("IsFleet() = %v, %v", fleet, err)
+	}
+}
+
+func TestResolveTargets_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "targets.txt")
+	_ = os.WriteFile(file, []byte("# tenants\n\npostgres://u:p@db1:5432/acme?sslmode=disable\nglobex   sqlite3:///data/globex.db\n"), 0644)
+
+	targets, err := resolveTargets(config.Targets{File: file})
+	if err != nil {
+		t.Fatalf("resolveTargets() error: %v", err)
+	}
+	if len(targets) != 2 || targets[0].Name != "acme" || targets[1].Name != "globex" ||
+		targets[1].DatabaseURL != "sqlite3:///data/globex.db" {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+
+	_ = os.WriteFile(file, []byte("a sqlite3:///a.db extra\n"), 0644)
+	if _, err := resolveTargets(config.Targets{File: file}); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("expected line error, got: %v", err)
+	}
+}
+
+func TestResolveTargets_Query(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE tenants (name TEXT, url TEXT, active INTEGER);
+		INSERT INTO tenants VALUES ('acme', 'sqlite3:///a.db', 1), ('old', 'sqlite3:///o.db', 0), ('globex', 'sqlite3:///g.db', 1);`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := config.TargetQuery{DatabaseURL: "sqlite3://" + path, SQL: "SELECT name, url FROM tenants WHERE active = 1 ORDER BY name DESC"}
+	targets, err := resolveTargets(config.Targets{Query: q})
+	if err != nil {
+		t.Fatalf("resolveTargets() error: %v", err)
+	}
+	if len(targets) != 2 || targets[0].Name != "globex" || targets[1].DatabaseURL != "sqlite3:///a.db" {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+
+	q.SQL = "SELECT url FROM tenants WHERE active = 1"
+	if targets, err = resolveTargets(config.Targets{Query: q}); err != nil || targets[0].Name != "a.db" {
+		t.Errorf("single-column query: %+v, %v", targets, err)
+	}
+
+	q.SQL = "SELECT name, url, active FROM tenants"
+	if _, err := resolveTargets(config.Targets{Query: q}); err == nil {
+		t.Error("expected error for three columns")
+	}
+}
+
+func TestResolveTargets_Errors(t *testing.T) {
+	dup := config.Targets{List: []config.Target{
+		{DatabaseURL: "postgres://db1/acme"},
+		{DatabaseURL: "postgres://db2/acme"},
+	}}
+	if _, err := resolveTargets(dup); err == nil || !strings.Contains(err.Error(), `"acme" listed twice`) {
+		t.Errorf("expected duplicate name error, got: %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	_ = os.WriteFile(empty, []byte("# nothing yet\n"), 0644)
+	if _, err := resolveTargets(config.Targets{File: empty}); err == nil {
+		t.Error("expected error for no targets")
+	}
+}
+
+func TestTargetName(t *testing.T) {
+	tests := map[string]string{
+		"postgres://u:p@host:5432/acme?sslmode=disable": "acme",
+		"mysql://u:p@tcp(host:3306)/globex?tls=true":    "globex",
+		"sqlite3:///var/data/initech.db":                "initech.db",
+	}
+	for url, want := range tests {
+		if got := targetName(url); got != want {
+			t.Errorf("targetName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package migrator
+
+import (
+	"fmt"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/schema"
+)
+
+// SchemaMigration is a migration generated from a desired schema
+type SchemaMigration struct {
+	Differences []schema.Difference
+	Up          []string
+	Down        []string
+}
+
+// MigrationFromSchema loads desiredSQL into the environment's scratch
+// database, compares it with the environment's database and generates the
+// statements that bring the database to the desired schema
+func MigrationFromSchema(cfg *config.Config, envName, desiredSQL string) (*SchemaMigration, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect := databaseDialect(env.DatabaseURL)
+	if env.ShadowDatabaseURL == "" && dialect != DialectSQLite {
+		return nil, fmt.Errorf("set shadow_database_url for environment %q to a scratch %s database to load the schema file", envName, dialect)
+	}
+	if shadow := databaseDialect(env.ShadowDatabaseURL); shadow != "" && shadow != dialect {
+		return nil, fmt.Errorf("shadow_database_url is %s but database_url is %s; both must use the same database", shadow, dialect)
+	}
+
+	scratchURL, cleanup, err := scratchDatabase(env)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	db, _, err := openDB(scratchURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec(desiredSQL); err != nil {
+		return nil, fmt.Errorf("load schema into scratch database: %w", err)
+	}
+	desired, err := schema.Inspect(db, dialect, migrationsTable(env.DatabaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("inspect desired schema: %w", err)
+	}
+
+	current, err := inspectURL(env.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	up, down := schema.Migrate(desired, current, dialect)
+	return &SchemaMigration{
+		Differences: schema.Diff(desired, current),
+		Up:          up,
+		Down:        down,
+	}, nil
+}
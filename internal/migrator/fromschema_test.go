@@ -0,0 +1,56 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationFromSchema(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(2); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	_ = mg.Close()
+
+	sm, err := MigrationFromSchema(envConfig(mg), "test", "CREATE TABLE a (id INT); CREATE TABLE c (id INT, name TEXT);")
+	if err != nil {
+		t.Fatalf("MigrationFromSchema() error: %v", err)
+	}
+
+	up := strings.Join(sm.Up, "\n")
+	down := strings.Join(sm.Down, "\n")
+	if !strings.Contains(up, "CREATE TABLE c (") || !strings.Contains(up, "DROP TABLE b;") {
+		t.Errorf("unexpected up:\n%s", up)
+	}
+	if !strings.Contains(down, "CREATE TABLE b (") || !strings.Contains(down, "DROP TABLE IF EXISTS c;") {
+		t.Errorf("unexpected down:\n%s", down)
+	}
+	if len(sm.Differences) != 2 {
+		t.Errorf("expected 2 differences, got %+v", sm.Differences)
+	}
+}
+
+func TestMigrationFromSchema_InvalidSQL(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	if _, err := MigrationFromSchema(envConfig(mg), "test", "CREATE TABLEX broken"); err == nil {
+		t.Error("expected error loading invalid schema")
+	}
+}
+
+func TestMigrationFromSchema_NeedsShadowForPostgres(t *testing.T) {
+	cfg := testConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"pg": map[string]interface{}{
+				"database_url":    "postgres://localhost:5432/app",
+				"migrations_path": t.TempDir(),
+			},
+		},
+	})
+
+	_, err := MigrationFromSchema(cfg, "pg", "CREATE TABLE a (id INT);")
+	if err == nil || !strings.Contains(err.Error(), "shadow_database_url") {
+		t.Errorf("expected shadow_database_url error, got: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// hookOutput receives hook progress and command output. Stdout is kept
+// free for --output and --progress=json.
+var hookOutput io.Writer = os.Stderr
+
+// hookRun describes a migration run to its hooks
+type hookRun struct {
+	direction  string
+	from, to   uint
+	migrations []PlannedMigration
+	// allowDirty runs hooks even when the database is dirty, for operations
+	// that do not refuse a dirty state
+	allowDirty bool
+}
+
+// withHooks runs op between the before and after hooks of its direction.
+// plan describes the run starting from the current version.
+// A failing before hook aborts the run; on_failure hooks run when a before
+// hook or the migration fails. Hooks are skipped when nothing would run or
+// the database is dirty (op then fails on its own, unless run.allowDirty).
+func (mg *Migrator) withHooks(plan func(from uint) hookRun, op func() error) error {
+	from, dirty, err := mg.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	run := plan(from)
+	if (dirty && !run.allowDirty) || len(run.migrations) == 0 {
+		return op()
+	}
+	direction := run.direction
+
+	before, after := config.HookBeforeUp, config.HookAfterUp
+	if direction == DirectionDown {
+		before, after = config.HookBeforeDown, config.HookAfterDown
+	}
+
+	if err := mg.runHooks(before, run, nil); err != nil {
+		err = fmt.Errorf("%s hook failed, no migrations were run: %w", before, err)
+		return mg.failureHooks(run, err)
+	}
+
+	if err := op(); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+		if v, _, verr := mg.m.Version(); verr == nil {
+			run.to = v
+		}
+		return mg.failureHooks(run, err)
+	}
+
+	if v, _, err := mg.m.Version(); err == nil || errors.Is(err, migrate.ErrNilVersion) {
+		run.to = v
+	}
+	if err := mg.runHooks(after, run, nil); err != nil {
+		return fmt.Errorf("%s hook failed (migrations were applied): %w", after, err)
+	}
+	return nil
+}
+
+// failureHooks runs the on_failure hooks for runErr and returns runErr,
+// joined with any hook error
+func (mg *Migrator) failureHooks(run hookRun, runErr error) error {
+	if err := mg.runHooks(config.HookOnFailure, run, runErr); err != nil {
+		return errors.Join(runErr, fmt.Errorf("%s hook failed: %w", config.HookOnFailure, err))
+	}
+	return runErr
+}
+
+// runHooks runs the hooks of a stage in order, stopping at the first failure
+func (mg *Migrator) runHooks(stage string, run hookRun, runErr error) error {
+	for i, h := range mg.env.Hooks.Stage(stage) {
+		var err error
+		if h.Command != "" {
+			_, _ = fmt.Fprintf(hookOutput, "Running %s hook: %s\n", stage, h.Command)
+			err = runHookCommand(h.Command, mg.hookEnv(stage, run, runErr))
+		} else {
+			_, _ = fmt.Fprintf(hookOutput, "Running %s hook: %s\n", stage, h.SQL)
+			err = mg.runHookSQL(h.SQL)
+		}
+		if err != nil {
+			return fmt.Errorf("hooks.%s[%d]: %w", stage, i, err)
+		}
+	}
+	return nil
+}
+
+// hookEnv returns the variables passed to hook commands
+func (mg *Migrator) hookEnv(stage string, run hookRun, runErr error) []string {
+	names := make([]string, len(run.migrations))
+	for i, m := range run.migrations {
+		names[i] = fmt.Sprintf("%06d_%s", m.Version, m.Name)
+	}
+
+	vars := []string{
+		"JANUS_HOOK=" + stage,
+		"JANUS_ENV=" + mg.envName,
+		"JANUS_DIRECTION=" + run.direction,
+		"JANUS_OLD_VERSION=" + strconv.FormatUint(uint64(run.from), 10),
+		"JANUS_NEW_VERSION=" + strconv.FormatUint(uint64(run.to), 10),
+		"JANUS_MIGRATIONS=" + strings.Join(names, ","),
+	}
+	if mg.schema != "" {
+		vars = append(vars, "JANUS_SCHEMA="+mg.schema)
+	}
+	if runErr != nil {
+		vars = append(vars, "JANUS_ERROR="+runErr.Error())
+	}
+	return vars
+}
+
+// runHookCommand runs a shell command with extra environment variables
+func runHookCommand(command string, vars []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), vars...)
+	cmd.Stdout = hookOutput
+	cmd.Stderr = hookOutput
+	return cmd.Run()
+}
+
+// runHookSQL executes a SQL file against the environment's database
+func (mg *Migrator) runHookSQL(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read SQL file: %w", err)
+	}
+
+	db, _, err := openDB(mg.env.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(string(content)); err != nil {
+		return fmt.Errorf("execute %s: %w", path, err)
+	}
+	return nil
+}
+
+// hookRunTo describes a run from one version to another
+func (mg *Migrator) hookRunTo(from, to uint) hookRun {
+	if to < from {
+		return hookRun{direction: DirectionDown, from: from, to: to, migrations: mg.plannedBetween(to, from, true)}
+	}
+	return hookRun{direction: DirectionUp, from: from, to: to, migrations: mg.plannedBetween(from, to, false)}
+}
+
+// stepsTarget returns the version reached by moving steps migrations from
+// version (negative steps move down; 0 if below the first migration)
+func (mg *Migrator) stepsTarget(version uint, steps int) uint {
+	target := version
+	for ; steps > 0; steps-- {
+		next, err := mg.sourceDriver.Next(target)
+		if err != nil {
+			break
+		}
+		target = next
+	}
+	for ; steps < 0; steps++ {
+		prev, err := mg.sourceDriver.Prev(target)
+		if err != nil {
+			return 0
+		}
+		target = prev
+	}
+	return target
+}
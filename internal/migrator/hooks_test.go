@@ -0,0 +1,164 @@
+package migrator
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+func quietHooks(t *testing.T) {
+	t.Helper()
+	old := hookOutput
+	hookOutput = io.Discard
+	t.Cleanup(func() { hookOutput = old })
+}
+
+func TestHooks_EnvironmentVariables(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	mg.env.Hooks.BeforeUp = []config.Hook{{Command: `echo "$JANUS_HOOK $JANUS_ENV $JANUS_OLD_VERSION $JANUS_NEW_VERSION $JANUS_MIGRATIONS" >> ` + out}}
+	mg.env.Hooks.AfterUp = []config.Hook{{Command: `echo "$JANUS_HOOK $JANUS_NEW_VERSION" >> ` + out}}
+
+	if err := mg.Up(2); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "before_up test 0 2 000001_a,000002_b\nafter_up 2\n"
+	if string(data) != want {
+		t.Errorf("hook output:\ngot:  %q\nwant: %q", data, want)
+	}
+}
+
+func TestHooks_FailedBeforeHookAborts(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	marker := filepath.Join(t.TempDir(), "failed")
+	mg.env.Hooks.BeforeUp = []config.Hook{{Command: "exit 3"}}
+	mg.env.Hooks.OnFailure = []config.Hook{{Command: "touch " + marker}}
+
+	err := mg.Up(0)
+	if err == nil || !strings.Contains(err.Error(), "before_up hook failed") {
+		t.Fatalf("expected before_up failure, got: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Applied != 0 {
+		t.Errorf("no migration should run after a failed before hook: %+v", status)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("on_failure hook should run")
+	}
+}
+
+func TestHooks_OnFailureGetsError(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, failingMigrationFiles)
+	out := filepath.Join(t.TempDir(), "error.txt")
+	mg.env.Hooks.OnFailure = []config.Hook{{Command: `echo "$JANUS_NEW_VERSION $JANUS_ERROR" > ` + out}}
+
+	if err := mg.Up(0); err == nil {
+		t.Fatal("expected migration 2 to fail")
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "2 ") || !strings.Contains(string(data), "syntax error") {
+		t.Errorf("on_failure should see the dirty version and error: %q", data)
+	}
+}
+
+func TestHooks_SQLFile(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	sqlFile := filepath.Join(t.TempDir(), "after.sql")
+	if err := os.WriteFile(sqlFile, []byte("INSERT INTO a (id) VALUES (1);\nINSERT INTO a (id) VALUES (2);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mg.env.Hooks.AfterUp = []config.Hook{{SQL: sqlFile}}
+
+	if err := mg.Up(0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+
+	db, _, err := openDB(mg.env.DatabaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM a").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("SQL hook should insert 2 rows, got %d", n)
+	}
+}
+
+func TestHooks_SkippedWhenNothingToRun(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	mg.env.Hooks.BeforeUp = []config.Hook{{Command: "exit 1"}}
+
+	if err := mg.Up(0); err == nil || strings.Contains(err.Error(), "hook") {
+		t.Errorf("expected ErrNoChange without running hooks, got: %v", err)
+	}
+}
+
+func TestHooks_Down(t *testing.T) {
+	quietHooks(t)
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "down.txt")
+	mg.env.Hooks.BeforeDown = []config.Hook{{Command: `echo "$JANUS_DIRECTION $JANUS_OLD_VERSION $JANUS_NEW_VERSION $JANUS_MIGRATIONS" > ` + out}}
+
+	if err := mg.Down(2); err != nil {
+		t.Fatalf("Down() error: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "down 3 1 000003_c,000002_b\n"; string(data) != want {
+		t.Errorf("hook output = %q, want %q", data, want)
+	}
+}
+
+func TestStepsTarget(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	tests := []struct {
+		from  uint
+		steps int
+		want  uint
+	}{
+		{0, 2, 2},
+		{0, 10, 3},
+		{3, -1, 2},
+		{2, -5, 0},
+	}
+	for _, tt := range tests {
+		if got := mg.stepsTarget(tt.from, tt.steps); got != tt.want {
+			t.Errorf("stepsTarget(%d, %d) = %d, want %d", tt.from, tt.steps, got, tt.want)
+		}
+	}
+}
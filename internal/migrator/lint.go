@@ -0,0 +1,35 @@
+package migrator
+
+import (
+	"fmt"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/lint"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// Lint checks an environment's migration files against the lint rules,
+// with the severities configured in janus.yaml. It does not connect to the
+// database; the database URL only selects dialect-specific rules. Returns
+// the findings and the number of migrations checked.
+func Lint(cfg *config.Config, envName string) ([]lint.Finding, int, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	src, err := singlefile.NewWithPath(env.MigrationsPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("source driver: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	driver := src.(*singlefile.Driver)
+	migrations := driver.GetMigrations()
+
+	linter := lint.New(databaseDialect(env.DatabaseURL), cfg.Lint)
+	var findings []lint.Finding
+	for _, v := range driver.GetVersions() {
+		findings = append(findings, linter.Lint(migrations[v])...)
+	}
+	return findings, len(migrations), nil
+}
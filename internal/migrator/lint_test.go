@@ -0,0 +1,36 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/cesc1802/janus/internal/lint"
+)
+
+func TestLint(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;",
+		"000002_b.sql": "-- +migrate lint-ignore:down_missing_if_exists\n-- +migrate UP\nDROP TABLE a;\n-- +migrate DOWN\nCREATE TABLE a (id INT);",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+	_ = mg.Close()
+
+	cfg := envConfig(mg)
+	cfg.Lint = map[string]string{"drop_table": "error"}
+
+	findings, count, err := Lint(cfg, "test")
+	if err != nil {
+		t.Fatalf("Lint() error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("checked %d migrations, want 2", count)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Version != 1 || f.Rule != lint.RuleDownMissingIfExists || f.Severity != lint.SeverityWarning {
+		t.Errorf("unexpected first finding: %+v", f)
+	}
+	if f := findings[1]; f.Version != 2 || f.Rule != lint.RuleDropTable || f.Severity != lint.SeverityError {
+		t.Errorf("unexpected second finding: %+v", f)
+	}
+}
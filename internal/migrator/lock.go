@@ -0,0 +1,218 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// defaultMigrationsTable is golang-migrate's version table name
+const defaultMigrationsTable = "schema_migrations"
+
+// ErrLockNotSupported is returned when the database keeps no lock that
+// outlives the migrating process (SQLite locks are in-process only)
+var ErrLockNotSupported = errors.New("database has no persistent migration lock")
+
+// LockInfo describes the migration lock of an environment's database
+type LockInfo struct {
+	Environment string      `json:"env" yaml:"env"`
+	Dialect     string      `json:"dialect" yaml:"dialect"`
+	Supported   bool        `json:"supported" yaml:"supported"`
+	Held        bool        `json:"held" yaml:"held"`
+	Holder      *LockHolder `json:"holder,omitempty" yaml:"holder,omitempty"`
+}
+
+// LockHolder identifies the database session holding the migration lock.
+// Fields the database cannot report are left empty.
+type LockHolder struct {
+	// ID is the backend PID (Postgres) or connection ID (MySQL)
+	ID          int64  `json:"id" yaml:"id"`
+	User        string `json:"user,omitempty" yaml:"user,omitempty"`
+	Host        string `json:"host,omitempty" yaml:"host,omitempty"`
+	Application string `json:"application,omitempty" yaml:"application,omitempty"`
+	// Since is when the holding session connected (Postgres only)
+	Since *time.Time `json:"since,omitempty" yaml:"since,omitempty"`
+	// Query is the statement the holding session last ran
+	Query string `json:"query,omitempty" yaml:"query,omitempty"`
+}
+
+// LockStatus reports who holds the migration lock of an environment.
+// It connects directly instead of through golang-migrate, which would wait
+// for the lock itself while setting up the version table.
+func LockStatus(cfg *config.Config, envName string) (*LockInfo, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &LockInfo{Environment: envName, Dialect: databaseDialect(env.DatabaseURL)}
+	if info.Dialect == DialectSQLite {
+		return info, nil
+	}
+
+	db, dialect, err := openDB(env.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	info.Supported = true
+	holder, err := lockHolder(db, dialect, env.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	info.Held = holder != nil
+	info.Holder = holder
+	return info, nil
+}
+
+// ReleaseLock breaks the migration lock by terminating the session holding
+// it. The lock is only released if it is still held by holderID, so a
+// session that took the lock after it was inspected is never killed.
+// Any migration the session was running is interrupted and the database
+// may be left dirty.
+func ReleaseLock(cfg *config.Config, envName string, holderID int64) error {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return err
+	}
+	if databaseDialect(env.DatabaseURL) == DialectSQLite {
+		return ErrLockNotSupported
+	}
+
+	db, dialect, err := openDB(env.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	holder, err := lockHolder(db, dialect, env.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		return fmt.Errorf("migration lock is not held")
+	}
+	if holder.ID != holderID {
+		return fmt.Errorf("migration lock is now held by session %d, not %d", holder.ID, holderID)
+	}
+
+	switch dialect {
+	case DialectPostgres:
+		var terminated bool
+		if err := db.QueryRow("SELECT pg_terminate_backend($1)", holderID).Scan(&terminated); err != nil {
+			return fmt.Errorf("terminate session %d: %w", holderID, err)
+		}
+		if !terminated {
+			return fmt.Errorf("session %d could not be terminated", holderID)
+		}
+	case DialectMySQL:
+		// KILL does not accept placeholders; holderID is an integer
+		if _, err := db.Exec("KILL " + strconv.FormatInt(holderID, 10)); err != nil {
+			return fmt.Errorf("kill connection %d: %w", holderID, err)
+		}
+	}
+	return nil
+}
+
+// lockHolder returns the session holding the migration lock (nil if free)
+func lockHolder(db *sql.DB, dialect, url string) (*LockHolder, error) {
+	switch dialect {
+	case DialectPostgres:
+		return postgresLockHolder(db, url)
+	case DialectMySQL:
+		return mysqlLockHolder(db, url)
+	}
+	return nil, ErrLockNotSupported
+}
+
+// quotedIdentPattern matches the parts of a quoted x-migrations-table value
+var quotedIdentPattern = regexp.MustCompile(`"(.*?)"`)
+
+// postgresLockHolder finds the advisory lock golang-migrate takes, keyed on
+// database name, schema and version table
+func postgresLockHolder(db *sql.DB, url string) (*LockHolder, error) {
+	var schema string
+	if err := db.QueryRow("SELECT CURRENT_SCHEMA()").Scan(&schema); err != nil {
+		return nil, fmt.Errorf("query current schema: %w", err)
+	}
+
+	table := migrationsTable(url)
+	if quoted, _ := strconv.ParseBool(urlParam(url, "x-migrations-table-quoted")); quoted {
+		parts := quotedIdentPattern.FindAllStringSubmatch(table, -1)
+		if len(parts) > 0 {
+			table = parts[len(parts)-1][1]
+		}
+		if len(parts) == 2 {
+			schema = parts[0][1]
+		}
+	}
+
+	aid, err := database.GenerateAdvisoryLockId(urlPath(url), schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("lock id: %w", err)
+	}
+
+	// A bigint advisory key is stored as classid (high) and objid (low)
+	const query = `SELECT l.pid, COALESCE(a.usename, ''), COALESCE(host(a.client_addr), ''),
+       COALESCE(a.application_name, ''), a.backend_start, COALESCE(a.query, '')
+FROM pg_locks l
+LEFT JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+  AND (l.classid::bigint << 32 | l.objid::bigint) = $1::bigint`
+
+	var h LockHolder
+	var since sql.NullTime
+	err = db.QueryRow(query, aid).Scan(&h.ID, &h.User, &h.Host, &h.Application, &since, &h.Query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query lock holder: %w", err)
+	}
+	if since.Valid {
+		h.Since = &since.Time
+	}
+	return &h, nil
+}
+
+// mysqlLockHolder finds the GET_LOCK name golang-migrate uses, keyed on
+// database name and version table
+func mysqlLockHolder(db *sql.DB, url string) (*LockHolder, error) {
+	var dbName sql.NullString
+	if err := db.QueryRow("SELECT DATABASE()").Scan(&dbName); err != nil {
+		return nil, fmt.Errorf("query database name: %w", err)
+	}
+
+	table := migrationsTable(url)
+	aid, err := database.GenerateAdvisoryLockId(fmt.Sprintf("%s:%s", dbName.String, table))
+	if err != nil {
+		return nil, fmt.Errorf("lock id: %w", err)
+	}
+
+	var id sql.NullInt64
+	if err := db.QueryRow("SELECT IS_USED_LOCK(?)", aid).Scan(&id); err != nil {
+		return nil, fmt.Errorf("query lock holder: %w", err)
+	}
+	if !id.Valid {
+		return nil, nil
+	}
+
+	h := &LockHolder{ID: id.Int64}
+	// Without the PROCESS privilege other users' connections are not visible
+	var info sql.NullString
+	err = db.QueryRow("SELECT USER, HOST, INFO FROM information_schema.PROCESSLIST WHERE ID = ?", id.Int64).
+		Scan(&h.User, &h.Host, &info)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("query process list: %w", err)
+	}
+	h.Query = info.String
+	return h, nil
+}
@@ -0,0 +1,40 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockStatus_SQLite(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	info, err := LockStatus(envConfig(mg), "test")
+	if err != nil {
+		t.Fatalf("LockStatus() error: %v", err)
+	}
+	if info.Supported || info.Held {
+		t.Errorf("SQLite should report no persistent lock: %+v", info)
+	}
+	if info.Dialect != DialectSQLite {
+		t.Errorf("Dialect = %q, want %q", info.Dialect, DialectSQLite)
+	}
+}
+
+func TestReleaseLock_SQLite(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	if err := ReleaseLock(envConfig(mg), "test", 1); !errors.Is(err, ErrLockNotSupported) {
+		t.Errorf("expected ErrLockNotSupported, got: %v", err)
+	}
+}
+
+func TestLockStatus_UnknownEnv(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	if _, err := LockStatus(envConfig(mg), "missing"); err == nil {
+		t.Error("expected error for unknown environment")
+	}
+}
@@ -1,9 +1,13 @@
 package migrator
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/source"
 
 	"github.com/cesc1802/janus/internal/config"
@@ -17,46 +21,99 @@ import (
 
 // Migrator wraps golang-migrate with our config and source driver
 type Migrator struct {
-	m            *migrate.Migrate
+	m *migrate.Migrate
+	// mu guards m, which fresh replaces while GracefulStop may read it
+	mu           sync.Mutex
 	env          config.Environment
 	envName      string
 	sourceDriver source.Driver
+	stopOnce     sync.Once
+	stopped      atomic.Bool
+	events       *eventLogger
+	// shadow marks a migrator running against a scratch database
+	shadow bool
+	// schema is the Postgres schema this migrator is bound to, if any
+	schema string
+	// schemas holds one child per schema for schema-per-tenant environments;
+	// such a migrator has no database connection of its own
+	schemas []*Migrator
+	// missing lists the listed schemas that do not exist yet
+	missing []string
+	// configDir is the config file's directory, which FailureDir is
+	// relative to
+	configDir string
 }
 
-// New creates a Migrator for the given environment
-func New(envName string) (*Migrator, error) {
-	_, err := config.Load()
+// New creates a Migrator for the given environment of cfg
+func New(cfg *config.Config, envName string) (*Migrator, error) {
+	env, err := cfg.Env(envName)
 	if err != nil {
-		return nil, fmt.Errorf("load config: %w", err)
+		return nil, err
 	}
-
-	env, err := config.GetEnv(envName)
+	mg, err := newEnvMigrator(envName, env)
 	if err != nil {
 		return nil, err
 	}
+	mg.useConfigDir(cfg)
+	return mg, nil
+}
 
+// newMigrator creates a Migrator for an environment's settings
+func newMigrator(envName string, env config.Environment) (*Migrator, error) {
 	// Create source driver
 	srcDriver, err := singlefile.NewWithPath(env.MigrationsPath)
 	if err != nil {
 		return nil, fmt.Errorf("source driver: %w", err)
 	}
 
-	// Create migrate instance
-	m, err := migrate.NewWithSourceInstance("singlefile", srcDriver, env.DatabaseURL)
-	if err != nil {
-		return nil, fmt.Errorf("migrate instance: %w", err)
-	}
-
-	return &Migrator{
-		m:            m,
+	mg := &Migrator{
 		env:          env,
 		envName:      envName,
 		sourceDriver: srcDriver,
-	}, nil
+	}
+	if err := mg.open(); err != nil {
+		return nil, err
+	}
+	return mg, nil
+}
+
+// open creates the golang-migrate instance, which connects to the database
+// and creates the version table if needed
+func (mg *Migrator) open() error {
+	dialect := databaseDialect(mg.env.DatabaseURL)
+	drv, err := database.Open(mg.env.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("migrate instance: %w", err)
+	}
+	// Apply session timeouts per migration where the database supports them
+	sessionDrv, err := newSessionDriver(drv, mg.sourceDriver.(*singlefile.Driver), mg.env, dialect)
+	if err != nil {
+		_ = drv.Close()
+		return err
+	}
+	m, err := migrate.NewWithInstance("singlefile", mg.sourceDriver, dialect, sessionDrv)
+	if err != nil {
+		_ = drv.Close()
+		return fmt.Errorf("migrate instance: %w", err)
+	}
+	if mg.events != nil {
+		m.Log = mg.events
+	}
+	mg.mu.Lock()
+	mg.m = m
+	mg.mu.Unlock()
+	return nil
 }
 
 // Close releases resources
 func (mg *Migrator) Close() error {
+	if mg.schemas != nil {
+		var errs []error
+		for _, child := range mg.schemas {
+			errs = append(errs, child.Close())
+		}
+		return errors.Join(append(errs, mg.sourceDriver.Close())...)
+	}
 	sourceErr, dbErr := mg.m.Close()
 	if sourceErr != nil {
 		return sourceErr
@@ -67,31 +124,99 @@ func (mg *Migrator) Close() error {
 // Up applies pending migrations
 // steps=0 means apply all, steps>0 means apply N migrations
 func (mg *Migrator) Up(steps int) error {
+	if mg.schemas != nil {
+		if err := mg.requireCreated(); err != nil {
+			return err
+		}
+		return mg.eachSchema(func(child *Migrator) error { return child.Up(steps) })
+	}
 	if steps > 0 {
-		return mg.m.Steps(steps)
+		return mg.withHooks(func(from uint) hookRun {
+			return mg.hookRunTo(from, mg.stepsTarget(from, steps))
+		}, func() error {
+			return mg.run(func() error { return mg.m.Steps(steps) })
+		})
 	}
-	return mg.m.Up()
+	return mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, mg.LatestVersion())
+	}, func() error {
+		return mg.run(mg.m.Up)
+	})
 }
 
 // Down rolls back migrations
 // steps=0 means rollback 1 (safety default), steps>0 means rollback N
 func (mg *Migrator) Down(steps int) error {
-	if steps > 0 {
-		return mg.m.Steps(-steps)
-	}
 	// Default: rollback 1 migration for safety
-	return mg.m.Steps(-1)
+	if steps <= 0 {
+		steps = 1
+	}
+	if mg.schemas != nil {
+		return mg.eachSchema(func(child *Migrator) error { return child.Down(steps) })
+	}
+	return mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, mg.stepsTarget(from, -steps))
+	}, func() error {
+		return mg.run(func() error { return mg.m.Steps(-steps) })
+	})
 }
 
 // Force sets migration version without running actual migration
 // Use this to fix dirty state
 func (mg *Migrator) Force(version int) error {
+	if mg.schemas != nil {
+		return mg.eachSchema(func(child *Migrator) error { return child.Force(version) })
+	}
 	return mg.m.Force(version)
 }
 
 // Goto migrates to a specific version (up or down)
 func (mg *Migrator) Goto(version uint) error {
-	return mg.m.Migrate(version)
+	if mg.schemas != nil {
+		if err := mg.requireCreated(); err != nil {
+			return err
+		}
+		return mg.eachSchema(func(child *Migrator) error { return child.Goto(version) })
+	}
+	return mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, version)
+	}, func() error {
+		return mg.run(func() error { return mg.m.Migrate(version) })
+	})
+}
+
+// run executes a golang-migrate operation, classifying its error, recording
+// a failure that left the database dirty and reporting the in-flight
+// migration as failed to event handlers
+func (mg *Migrator) run(op func() error) error {
+	before, _, _ := mg.m.Version()
+	err := mg.classifyError(op())
+	mg.recordFailure(before, err)
+	if mg.events != nil && err != migrate.ErrNoChange {
+		mg.events.fail(err)
+	}
+	return err
+}
+
+// GracefulStop asks the migrator to stop once the running migration finishes.
+// No new migration is started afterwards. Safe to call from any goroutine.
+func (mg *Migrator) GracefulStop() {
+	mg.stopOnce.Do(func() {
+		mg.stopped.Store(true)
+		for _, child := range mg.schemas {
+			child.GracefulStop()
+		}
+		mg.mu.Lock()
+		defer mg.mu.Unlock()
+		if mg.m != nil {
+			mg.m.GracefulStop <- true
+		}
+	})
+}
+
+// Stopped returns whether GracefulStop was called
+func (mg *Migrator) Stopped() bool {
+	return mg.stopped.Load()
 }
 
 // RequiresConfirmation returns whether this env needs user confirmation
@@ -0,0 +1,215 @@
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// PlanFormatVersion is bumped whenever the plan file layout changes
+const PlanFormatVersion = 1
+
+// Plan is a reviewable record of exactly what a migration run will do
+type Plan struct {
+	FormatVersion  int                `json:"format_version"`
+	Environment    string             `json:"environment"`
+	CurrentVersion uint               `json:"current_version"`
+	TargetVersion  uint               `json:"target_version"`
+	Direction      string             `json:"direction"`
+	Migrations     []PlannedMigration `json:"migrations"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// PlannedMigration is a single migration step recorded in a plan
+type PlannedMigration struct {
+	Version  uint   `json:"version"`
+	Name     string `json:"name"`
+	Checksum string `json:"checksum"`
+}
+
+// Plan direction values
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+	DirectionNone = "none"
+)
+
+// Plan builds a plan that takes the database from its current version to target
+func (mg *Migrator) Plan(target uint) (*Plan, error) {
+	if err := mg.requireSingle(); err != nil {
+		return nil, err
+	}
+	status, err := mg.Status()
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("cannot plan: database in dirty state at version %d", status.Version)
+	}
+
+	if target != 0 {
+		if _, ok := mg.migrations()[target]; !ok {
+			return nil, fmt.Errorf("target version %d not found in migrations", target)
+		}
+	}
+
+	p := &Plan{
+		FormatVersion:  PlanFormatVersion,
+		Environment:    mg.envName,
+		CurrentVersion: status.Version,
+		TargetVersion:  target,
+		Direction:      DirectionNone,
+		Migrations:     []PlannedMigration{},
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	switch {
+	case target > status.Version:
+		p.Direction = DirectionUp
+		p.Migrations = mg.plannedBetween(status.Version, target, false)
+	case target < status.Version:
+		p.Direction = DirectionDown
+		p.Migrations = mg.plannedBetween(target, status.Version, true)
+	}
+
+	return p, nil
+}
+
+// LatestVersion returns the highest migration version available in the source
+// (0 if there are no migrations)
+func (mg *Migrator) LatestVersion() uint {
+	versions := mg.fileSource().GetVersions()
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1]
+}
+
+// VerifyPlan checks that the database and migration files still match the plan.
+// Returns an error describing every difference found.
+func (mg *Migrator) VerifyPlan(p *Plan) error {
+	if err := mg.requireSingle(); err != nil {
+		return err
+	}
+	if p.FormatVersion != PlanFormatVersion {
+		return fmt.Errorf("unsupported plan format version %d (expected %d)", p.FormatVersion, PlanFormatVersion)
+	}
+	if p.Environment != mg.envName {
+		return fmt.Errorf("plan is for environment %q, not %q", p.Environment, mg.envName)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("get status: %w", err)
+	}
+	if status.Dirty {
+		return fmt.Errorf("database in dirty state at version %d", status.Version)
+	}
+	if status.Version != p.CurrentVersion {
+		return fmt.Errorf("database is at version %d, plan was made at version %d", status.Version, p.CurrentVersion)
+	}
+
+	current, err := mg.Plan(p.TargetVersion)
+	if err != nil {
+		return err
+	}
+
+	var diffs []string
+	planned := make(map[uint]PlannedMigration, len(p.Migrations))
+	for _, pm := range p.Migrations {
+		planned[pm.Version] = pm
+	}
+	found := make(map[uint]bool, len(current.Migrations))
+	for _, cm := range current.Migrations {
+		found[cm.Version] = true
+		pm, ok := planned[cm.Version]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("migration %d (%s) is not in the plan", cm.Version, cm.Name))
+			continue
+		}
+		if pm.Checksum != cm.Checksum {
+			diffs = append(diffs, fmt.Sprintf("migration %d (%s) checksum changed", cm.Version, cm.Name))
+		}
+	}
+	for _, pm := range p.Migrations {
+		if !found[pm.Version] {
+			diffs = append(diffs, fmt.Sprintf("migration %d (%s) no longer exists", pm.Version, pm.Name))
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("migrations differ from plan:\n  %s", strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
+
+// ApplyPlan migrates to the target version of a plan checked with
+// VerifyPlan
+func (mg *Migrator) ApplyPlan(p *Plan) error {
+	if p.Direction == DirectionNone {
+		return nil
+	}
+	if p.TargetVersion == 0 {
+		// No migration file has version 0 to go to: roll back every
+		// applied migration instead
+		return mg.Down(len(p.Migrations))
+	}
+	return mg.Goto(p.TargetVersion)
+}
+
+// WritePlanFile writes the plan as indented JSON
+func WritePlanFile(path string, p *Plan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadPlanFile reads a plan written by WritePlanFile
+func ReadPlanFile(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read plan: %w", err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decode plan %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// plannedBetween lists migrations in (from, to], descending when reverse is set
+func (mg *Migrator) plannedBetween(from, to uint, reverse bool) []PlannedMigration {
+	migrations := mg.migrations()
+	list := []PlannedMigration{}
+	for _, v := range mg.fileSource().GetVersions() {
+		if v > from && v <= to {
+			m := migrations[v]
+			list = append(list, PlannedMigration{Version: v, Name: m.Name, Checksum: m.Checksum})
+		}
+	}
+	if reverse {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+	return list
+}
+
+// fileSource returns the concrete single-file source driver
+func (mg *Migrator) fileSource() *singlefile.Driver {
+	return mg.sourceDriver.(*singlefile.Driver)
+}
+
+// migrations returns parsed migrations keyed by version
+func (mg *Migrator) migrations() map[uint]singlefile.Migration {
+	return mg.fileSource().GetMigrations()
+}
@@ -0,0 +1,171 @@
+package migrator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlan_Up(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	p, err := mg.Plan(mg.LatestVersion())
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+
+	if p.Direction != DirectionUp {
+		t.Errorf("Direction = %s, want %s", p.Direction, DirectionUp)
+	}
+	if p.CurrentVersion != 0 || p.TargetVersion != 3 {
+		t.Errorf("versions = %d -> %d, want 0 -> 3", p.CurrentVersion, p.TargetVersion)
+	}
+	if len(p.Migrations) != 3 || p.Migrations[0].Version != 1 {
+		t.Fatalf("unexpected migrations: %+v", p.Migrations)
+	}
+	if p.Migrations[0].Checksum == "" {
+		t.Error("planned migration should carry a checksum")
+	}
+}
+
+func TestPlan_Down(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := mg.Plan(1)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+
+	if p.Direction != DirectionDown {
+		t.Errorf("Direction = %s, want %s", p.Direction, DirectionDown)
+	}
+	if len(p.Migrations) != 2 || p.Migrations[0].Version != 3 || p.Migrations[1].Version != 2 {
+		t.Errorf("down plan should list 3, 2 in order: %+v", p.Migrations)
+	}
+}
+
+func TestPlan_UnknownTarget(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	if _, err := mg.Plan(42); err == nil {
+		t.Error("expected error for unknown target version")
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	p, err := mg.Plan(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.ApplyPlan(p); err != nil {
+		t.Fatalf("ApplyPlan() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 2 {
+		t.Errorf("Version = %d, want 2", status.Version)
+	}
+}
+
+func TestApplyPlan_RollbackToZero(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := mg.Plan(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Direction != DirectionDown || len(p.Migrations) != 3 {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+	if err := mg.ApplyPlan(p); err != nil {
+		t.Fatalf("ApplyPlan() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 0 || status.Applied != 0 {
+		t.Errorf("status = %+v, want every migration rolled back", status)
+	}
+}
+
+func TestVerifyPlan_VersionChanged(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	p, err := mg.Plan(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Up(1); err != nil {
+		t.Fatal(err)
+	}
+
+	err = mg.VerifyPlan(p)
+	if err == nil || !strings.Contains(err.Error(), "plan was made at version 0") {
+		t.Errorf("expected version mismatch error, got: %v", err)
+	}
+}
+
+func TestVerifyPlan_ChecksumChanged(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	p, err := mg.Plan(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Migrations[1].Checksum = "tampered"
+
+	err = mg.VerifyPlan(p)
+	if err == nil || !strings.Contains(err.Error(), "migration 2 (b) checksum changed") {
+		t.Errorf("expected checksum error, got: %v", err)
+	}
+}
+
+func TestVerifyPlan_WrongEnvironment(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	p, err := mg.Plan(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Environment = "prod"
+
+	if err := mg.VerifyPlan(p); err == nil {
+		t.Error("expected error for plan made against another environment")
+	}
+}
+
+func TestPlanFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	p := &Plan{
+		FormatVersion:  PlanFormatVersion,
+		Environment:    "prod",
+		CurrentVersion: 1,
+		TargetVersion:  2,
+		Direction:      DirectionUp,
+		Migrations:     []PlannedMigration{{Version: 2, Name: "b", Checksum: "abc"}},
+	}
+
+	if err := WritePlanFile(path, p); err != nil {
+		t.Fatalf("WritePlanFile() error: %v", err)
+	}
+	got, err := ReadPlanFile(path)
+	if err != nil {
+		t.Fatalf("ReadPlanFile() error: %v", err)
+	}
+	if got.Environment != "prod" || len(got.Migrations) != 1 || got.Migrations[0].Checksum != "abc" {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+}
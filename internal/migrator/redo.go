@@ -0,0 +1,92 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RedoError reports the migration a redo stopped at
+type RedoError struct {
+	Direction string
+	Version   uint
+	Name      string
+	Err       error
+}
+
+func (e *RedoError) Error() string {
+	return fmt.Sprintf("stopped at %s of %06d %s: %v", strings.ToUpper(e.Direction), e.Version, e.Name, e.Err)
+}
+
+func (e *RedoError) Unwrap() error {
+	return e.Err
+}
+
+// RedoMigrations returns the last n applied migrations, newest first
+func (mg *Migrator) RedoMigrations(n int) ([]MigrationInfo, error) {
+	if err := mg.requireSingle(); err != nil {
+		return nil, err
+	}
+	status, err := mg.Status()
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("database in dirty state at version %d", status.Version)
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	if n > status.Applied {
+		return nil, fmt.Errorf("cannot redo %d migration(s): only %d applied", n, status.Applied)
+	}
+
+	applied := mg.MigrationsBetween(0, status.Version)
+	list := make([]MigrationInfo, 0, n)
+	for i := len(applied) - 1; i >= len(applied)-n; i-- {
+		list = append(list, applied[i])
+	}
+	return list, nil
+}
+
+// Redo rolls back the last steps applied migrations and applies them again.
+// Migrations run one at a time so a failure is reported as a *RedoError
+// naming the exact migration and direction; nothing runs after it.
+func (mg *Migrator) Redo(steps int) error {
+	list, err := mg.RedoMigrations(steps)
+	if err != nil {
+		return err
+	}
+
+	err = mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, mg.stepsTarget(from, -steps))
+	}, func() error {
+		for _, m := range list {
+			if mg.Stopped() {
+				return nil
+			}
+			if err := mg.run(func() error { return mg.m.Steps(-1) }); err != nil {
+				return &RedoError{Direction: DirectionDown, Version: m.Version, Name: m.Name, Err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil || mg.Stopped() {
+		return err
+	}
+
+	err = mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, mg.stepsTarget(from, steps))
+	}, func() error {
+		for i := len(list) - 1; i >= 0; i-- {
+			if mg.Stopped() {
+				return nil
+			}
+			m := list[i]
+			if err := mg.run(func() error { return mg.m.Steps(1) }); err != nil {
+				return &RedoError{Direction: DirectionUp, Version: m.Version, Name: m.Name, Err: err}
+			}
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRedoMigrations(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := mg.RedoMigrations(2)
+	if err != nil {
+		t.Fatalf("RedoMigrations() error: %v", err)
+	}
+	if len(list) != 2 || list[0].Version != 3 || list[1].Version != 2 {
+		t.Errorf("expected 3, 2 newest first: %+v", list)
+	}
+
+	if _, err := mg.RedoMigrations(4); err == nil {
+		t.Error("expected error when redoing more than applied")
+	}
+}
+
+func TestRedo(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mg.Redo(2); err != nil {
+		t.Fatalf("Redo() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 3 || status.Dirty {
+		t.Errorf("status = %+v, want clean at 3", status)
+	}
+}
+
+func TestRedo_StopsAtFailedDown(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nNOT VALID SQL;",
+		"000002_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\n-- +migrate DOWN\nDROP TABLE b;",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mg.Redo(2)
+	var redoErr *RedoError
+	if !errors.As(err, &redoErr) {
+		t.Fatalf("expected *RedoError, got: %v", err)
+	}
+	if redoErr.Direction != DirectionDown || redoErr.Version != 1 {
+		t.Errorf("should stop at DOWN of 1: %+v", redoErr)
+	}
+
+	// Migration 2 was rolled back and must not be re-applied
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Applied > 1 {
+		t.Errorf("nothing should run after the failure: %+v", status)
+	}
+}
@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// RepairAction is a way to recover from a dirty state
+type RepairAction string
+
+// Repair actions offered by 'janus repair'
+const (
+	// RepairRerunUp marks the version before the failed migration clean and
+	// runs the failed migration's UP again
+	RepairRerunUp RepairAction = "rerun-up"
+	// RepairRunDown marks the failed migration's version clean and runs its DOWN
+	RepairRunDown RepairAction = "run-down"
+	// RepairMarkPrevious marks the version before the failed migration clean
+	RepairMarkPrevious RepairAction = "mark-previous"
+	// RepairMarkCurrent marks the failed migration's version clean
+	RepairMarkCurrent RepairAction = "mark-current"
+)
+
+// RepairActions lists the repair actions in the order they are offered
+var RepairActions = []RepairAction{RepairRerunUp, RepairRunDown, RepairMarkPrevious, RepairMarkCurrent}
+
+// ErrNotDirty is returned by DirtyState when there is nothing to repair
+var ErrNotDirty = errors.New("database is not in a dirty state")
+
+// DirtyState describes a dirty database and the migration that left it so
+type DirtyState struct {
+	// Version is the dirty version recorded in the database
+	Version uint
+	// Migration is the version of the migration that failed
+	Migration uint
+	Name      string
+	// Direction is DirectionUp or DirectionDown, or "" when no failure was
+	// recorded and an UP failure is assumed
+	Direction string
+	UpSQL     string
+	DownSQL   string
+	// PreviousVersion is the version below Migration (database.NilVersion
+	// when Migration is the first one)
+	PreviousVersion int
+	// Failure is the recorded failure, if this machine recorded it
+	Failure *Failure
+}
+
+// DirtyState inspects a dirty database. Returns ErrNotDirty when clean.
+func (mg *Migrator) DirtyState() (*DirtyState, error) {
+	if err := mg.requireSingle(); err != nil {
+		return nil, err
+	}
+	status, err := mg.Status()
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+	if !status.Dirty {
+		return nil, ErrNotDirty
+	}
+
+	ds := &DirtyState{Version: status.Version, Migration: status.Version}
+
+	failure, err := mg.LastFailure()
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil && failure.matches(status.Version, mg.sourceDriver) {
+		ds.Failure = failure
+		ds.Direction = failure.Direction
+		ds.Migration = failure.Version
+	}
+
+	m, ok := mg.migrations()[ds.Migration]
+	if !ok {
+		return nil, fmt.Errorf("dirty version %d has no migration file", ds.Migration)
+	}
+	ds.Name, ds.UpSQL, ds.DownSQL = m.Name, m.Up, m.Down
+
+	ds.PreviousVersion = database.NilVersion
+	if prev, err := mg.sourceDriver.Prev(ds.Migration); err == nil {
+		ds.PreviousVersion = int(prev)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// matches reports whether f explains the given dirty version
+func (f *Failure) matches(dirtyVersion uint, src source.Driver) bool {
+	if f.Direction == DirectionUp {
+		return f.Version == dirtyVersion
+	}
+	next, err := src.Next(dirtyVersion)
+	return err == nil && next == f.Version
+}
+
+// Repair recovers from the dirty state described by ds.
+// Actions that run a migration first mark the right version clean, then
+// run exactly one migration. The failure record is cleared on success.
+func (mg *Migrator) Repair(ds *DirtyState, action RepairAction) error {
+	if err := mg.requireSingle(); err != nil {
+		return err
+	}
+	var err error
+	switch action {
+	case RepairRerunUp:
+		if err = mg.m.Force(ds.PreviousVersion); err == nil {
+			err = mg.run(func() error { return mg.m.Steps(1) })
+		}
+	case RepairRunDown:
+		if err = mg.m.Force(int(ds.Migration)); err == nil {
+			err = mg.run(func() error { return mg.m.Steps(-1) })
+		}
+	case RepairMarkPrevious:
+		err = mg.m.Force(ds.PreviousVersion)
+	case RepairMarkCurrent:
+		err = mg.m.Force(int(ds.Migration))
+	default:
+		return fmt.Errorf("unknown repair action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+	return mg.clearFailure()
+}
@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+var failingMigrationFiles = map[string]string{
+	"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;",
+	"000002_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\nNOT VALID SQL;\n-- +migrate DOWN\nDROP TABLE IF EXISTS b;",
+}
+
+func TestDirtyState_NotDirty(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	if _, err := mg.DirtyState(); !errors.Is(err, ErrNotDirty) {
+		t.Errorf("expected ErrNotDirty, got: %v", err)
+	}
+}
+
+func TestDirtyState_FailedUp(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, failingMigrationFiles)
+	if err := mg.Up(0); err == nil {
+		t.Fatal("expected migration 2 to fail")
+	}
+
+	ds, err := mg.DirtyState()
+	if err != nil {
+		t.Fatalf("DirtyState() error: %v", err)
+	}
+	if ds.Version != 2 || ds.Migration != 2 || ds.Name != "b" {
+		t.Errorf("unexpected dirty state: %+v", ds)
+	}
+	if ds.PreviousVersion != 1 {
+		t.Errorf("PreviousVersion = %d, want 1", ds.PreviousVersion)
+	}
+	if ds.Direction != DirectionUp || ds.Failure == nil || ds.Failure.Error == "" {
+		t.Errorf("failure should be recorded: %+v", ds)
+	}
+}
+
+func TestDirtyState_FailedDown(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;",
+		"000002_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\n-- +migrate DOWN\nNOT VALID SQL;",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Down(1); err == nil {
+		t.Fatal("expected DOWN of migration 2 to fail")
+	}
+
+	ds, err := mg.DirtyState()
+	if err != nil {
+		t.Fatalf("DirtyState() error: %v", err)
+	}
+	if ds.Version != 1 || ds.Migration != 2 || ds.Direction != DirectionDown {
+		t.Errorf("DOWN failure should point at migration 2: %+v", ds)
+	}
+}
+
+func TestRepair_MarkPrevious(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, failingMigrationFiles)
+	_ = mg.Up(0)
+
+	ds, err := mg.DirtyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Repair(ds, RepairMarkPrevious); err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 || status.Dirty {
+		t.Errorf("status = %+v, want clean at 1", status)
+	}
+	if f, _ := mg.LastFailure(); f != nil {
+		t.Errorf("failure record should be cleared: %+v", f)
+	}
+}
+
+func TestRepair_RunDown(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, failingMigrationFiles)
+	_ = mg.Up(0)
+
+	ds, err := mg.DirtyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Repair(ds, RepairRunDown); err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 || status.Dirty {
+		t.Errorf("status = %+v, want clean at 1", status)
+	}
+}
+
+func TestRepair_RerunUpFailsAgain(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, failingMigrationFiles)
+	_ = mg.Up(0)
+
+	ds, err := mg.DirtyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Repair(ds, RepairRerunUp); err == nil {
+		t.Fatal("expected re-run of broken UP to fail")
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 2 || !status.Dirty {
+		t.Errorf("status = %+v, want dirty at 2", status)
+	}
+	if f, _ := mg.LastFailure(); f == nil {
+		t.Error("new failure should be recorded")
+	}
+}
@@ -0,0 +1,80 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// ErrResetNotAllowed is returned by Reset and Fresh for environments that
+// require confirmation and do not set allow_reset
+var ErrResetNotAllowed = errors.New("reset and fresh are disabled for environments with require_confirmation (set allow_reset: true to enable)")
+
+// ResetAllowed reports whether Reset and Fresh may run in this environment
+func (mg *Migrator) ResetAllowed() bool {
+	return !mg.env.RequireConfirmation || mg.env.AllowReset
+}
+
+// Reset rolls back every applied migration
+func (mg *Migrator) Reset() error {
+	if !mg.ResetAllowed() {
+		return ErrResetNotAllowed
+	}
+	if mg.schemas != nil {
+		return mg.eachSchema(func(child *Migrator) error { return child.Reset() })
+	}
+	return mg.withHooks(func(from uint) hookRun {
+		return mg.hookRunTo(from, 0)
+	}, func() error {
+		return mg.run(mg.m.Down)
+	})
+}
+
+// Fresh drops every table, the version table included, and applies all
+// migrations from scratch. DOWN sections are not run.
+func (mg *Migrator) Fresh() error {
+	if !mg.ResetAllowed() {
+		return ErrResetNotAllowed
+	}
+	if mg.schemas != nil {
+		if err := mg.requireCreated(); err != nil {
+			return err
+		}
+		return mg.eachSchema(func(child *Migrator) error { return child.Fresh() })
+	}
+	latest := mg.LatestVersion()
+	return mg.withHooks(func(from uint) hookRun {
+		// Every migration runs again, whatever the current version
+		return hookRun{
+			direction:  DirectionUp,
+			from:       from,
+			to:         latest,
+			migrations: mg.plannedBetween(0, latest, false),
+			allowDirty: true,
+		}
+	}, mg.fresh)
+}
+
+// fresh drops all tables and applies every migration
+func (mg *Migrator) fresh() error {
+	if err := mg.m.Drop(); err != nil {
+		return fmt.Errorf("drop: %w", err)
+	}
+
+	// Reconnect so golang-migrate creates the dropped version table again
+	if err := mg.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := mg.open(); err != nil {
+		return err
+	}
+	// A stop received during the drop went to the closed instance
+	if mg.Stopped() {
+		return nil
+	}
+	if err := mg.run(mg.m.Up); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
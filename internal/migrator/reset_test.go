@@ -0,0 +1,128 @@
+package migrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+)
+
+func TestReset(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mg.Reset(); err != nil {
+		t.Fatalf("Reset() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Applied != 0 {
+		t.Errorf("all migrations should be rolled back: %+v", status)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nNOT VALID SQL;",
+		"000002_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\n-- +migrate DOWN\nNOT VALID SQL;",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+	if err := mg.Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Broken DOWN sections don't matter: fresh drops instead
+	if err := mg.Fresh(); err != nil {
+		t.Fatalf("Fresh() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 2 || status.Dirty {
+		t.Errorf("status = %+v, want clean at 2", status)
+	}
+}
+
+func TestReset_NotAllowed(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	mg.env.RequireConfirmation = true
+
+	if mg.ResetAllowed() {
+		t.Error("reset should be disabled with require_confirmation")
+	}
+	if err := mg.Reset(); !errors.Is(err, ErrResetNotAllowed) {
+		t.Errorf("Reset() = %v, want ErrResetNotAllowed", err)
+	}
+	if err := mg.Fresh(); !errors.Is(err, ErrResetNotAllowed) {
+		t.Errorf("Fresh() = %v, want ErrResetNotAllowed", err)
+	}
+
+	mg.env.AllowReset = true
+	if !mg.ResetAllowed() {
+		t.Error("allow_reset should enable reset")
+	}
+}
+
+// dropHookDriver is the sqlite3 driver under the "drophook" scheme, calling
+// onDrop when the database is dropped
+type dropHookDriver struct {
+	database.Driver
+}
+
+var onDrop func()
+
+func init() {
+	database.Register("drophook", dropHookDriver{})
+}
+
+func (dropHookDriver) Open(url string) (database.Driver, error) {
+	drv, err := (&sqlite3.Sqlite{}).Open("sqlite3" + strings.TrimPrefix(url, "drophook"))
+	if err != nil {
+		return nil, err
+	}
+	return dropHookDriver{drv}, nil
+}
+
+func (d dropHookDriver) Drop() error {
+	if onDrop != nil {
+		onDrop()
+	}
+	return d.Driver.Drop()
+}
+
+func TestFresh_StopDuringDrop(t *testing.T) {
+	setup, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	if err := setup.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	env := setup.env
+	env.DatabaseURL = "drophook" + strings.TrimPrefix(env.DatabaseURL, "sqlite3")
+	mg, err := newMigrator("test", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mg.Close() }()
+
+	onDrop = mg.GracefulStop
+	defer func() { onDrop = nil }()
+	if err := mg.Fresh(); err != nil {
+		t.Fatalf("Fresh() error: %v", err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Applied != 0 {
+		t.Errorf("no migration should run after a stop during the drop: %+v", status)
+	}
+}
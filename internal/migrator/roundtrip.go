@@ -0,0 +1,167 @@
+package migrator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/schema"
+)
+
+// RoundTripResult reports the UP, DOWN, UP check of one migration
+type RoundTripResult struct {
+	Version uint   `json:"version" yaml:"version"`
+	Name    string `json:"name" yaml:"name"`
+	// Problems is empty when the migration round-trips cleanly
+	Problems []string `json:"problems,omitempty" yaml:"problems,omitempty"`
+	// Changes lists the schema objects that differ from what was expected
+	Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// OK reports whether the migration round-tripped cleanly
+func (r RoundTripResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// RoundTrip checks that every migration of an environment can be reversed.
+// Against a scratch database (shadow_database_url, or a temporary SQLite
+// file) each migration is applied, rolled back and applied again, comparing
+// a schema fingerprint after each step. The scratch database is wiped first.
+// onResult, if set, is called as each migration is checked. A migration that
+// fails to run ends the check; its result is included and the error returned.
+func RoundTrip(cfg *config.Config, envName string, onResult func(RoundTripResult)) ([]RoundTripResult, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	shadowURL, cleanup, err := scratchDatabase(env)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	// Hooks act on real systems; they never run against the scratch database
+	shadowEnv := env
+	shadowEnv.DatabaseURL = shadowURL
+	shadowEnv.Hooks = config.Hooks{}
+	mg, err := newMigrator(envName, shadowEnv)
+	if err != nil {
+		return nil, fmt.Errorf("scratch database: %w", err)
+	}
+	mg.shadow = true
+	defer func() { _ = mg.Close() }()
+
+	db, dialect, err := openDB(shadowURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	inspect := func() (*schema.Schema, error) {
+		return schema.Inspect(db, dialect, migrationsTable(shadowURL))
+	}
+
+	var results []RoundTripResult
+	report := func(r RoundTripResult) {
+		results = append(results, r)
+		if onResult != nil {
+			onResult(r)
+		}
+	}
+
+	for _, pm := range mg.plannedBetween(0, mg.LatestVersion(), false) {
+		r := RoundTripResult{Version: pm.Version, Name: pm.Name}
+
+		before, err := inspect()
+		if err != nil {
+			return results, err
+		}
+		if err := mg.run(func() error { return mg.m.Steps(1) }); err != nil {
+			r.Problems = append(r.Problems, "UP failed: "+err.Error())
+			report(r)
+			return results, fmt.Errorf("migration %d UP failed: %w", pm.Version, err)
+		}
+		applied, err := inspect()
+		if err != nil {
+			return results, err
+		}
+
+		if err := mg.run(func() error { return mg.m.Steps(-1) }); err != nil {
+			r.Problems = append(r.Problems, "DOWN failed: "+err.Error())
+			report(r)
+			return results, fmt.Errorf("migration %d DOWN failed: %w", pm.Version, err)
+		}
+		reverted, err := inspect()
+		if err != nil {
+			return results, err
+		}
+		if reverted.Fingerprint() != before.Fingerprint() {
+			r.Problems = append(r.Problems, "DOWN does not restore the previous schema")
+			r.Changes = append(r.Changes, schemaChanges(before, reverted)...)
+		}
+
+		if err := mg.run(func() error { return mg.m.Steps(1) }); err != nil {
+			r.Problems = append(r.Problems, "UP after DOWN failed: "+err.Error())
+			report(r)
+			return results, fmt.Errorf("migration %d UP after DOWN failed: %w", pm.Version, err)
+		}
+		reapplied, err := inspect()
+		if err != nil {
+			return results, err
+		}
+		if reapplied.Fingerprint() != applied.Fingerprint() {
+			r.Problems = append(r.Problems, "UP after DOWN produces a different schema")
+			r.Changes = append(r.Changes, schemaChanges(applied, reapplied)...)
+		}
+
+		report(r)
+	}
+	return results, nil
+}
+
+// schemaChanges lists tables and views that differ between an expected and
+// an actual schema
+func schemaChanges(want, got *schema.Schema) []string {
+	wantObjs, gotObjs := schemaObjects(want), schemaObjects(got)
+	var changes []string
+	for _, name := range sortedNames(wantObjs, gotObjs) {
+		w, inWant := wantObjs[name]
+		g, inGot := gotObjs[name]
+		switch {
+		case !inGot:
+			changes = append(changes, name+" is missing")
+		case !inWant:
+			changes = append(changes, name+" was left behind")
+		case w != g:
+			changes = append(changes, name+" differs")
+		}
+	}
+	return changes
+}
+
+// schemaObjects maps "table x" / "view x" to the object's normalized SQL
+func schemaObjects(s *schema.Schema) map[string]string {
+	objs := make(map[string]string, len(s.Tables)+len(s.Views))
+	for _, t := range s.Tables {
+		objs["table "+t.Name] = t.SQL()
+	}
+	for _, v := range s.Views {
+		objs["view "+v.Name] = v.SQL()
+	}
+	return objs
+}
+
+func sortedNames(maps ...map[string]string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range maps {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cesc1802/janus/internal/schema"
+)
+
+func TestRoundTrip_Reversible(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	var seen int
+	results, err := RoundTrip(envConfig(mg), "test", func(RoundTripResult) { seen++ })
+	if err != nil {
+		t.Fatalf("RoundTrip() error: %v", err)
+	}
+	if len(results) != 3 || seen != 3 {
+		t.Fatalf("expected 3 results, got %d (callbacks: %d)", len(results), seen)
+	}
+	for _, r := range results {
+		if !r.OK() {
+			t.Errorf("migration %d should round-trip: %+v", r.Version, r)
+		}
+	}
+}
+
+func TestRoundTrip_BrokenDown(t *testing.T) {
+	files := map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;",
+		"000002_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\nCREATE INDEX idx_b ON b (id);\n-- +migrate DOWN\nDROP INDEX idx_b;",
+	}
+	mg, _ := setupSQLiteMigrator(t, files)
+	_ = mg.Close()
+
+	results, err := RoundTrip(envConfig(mg), "test", nil)
+	if err == nil {
+		t.Fatal("expected re-applying UP to fail on the leftover table")
+	}
+	if len(results) != 2 || !results[0].OK() {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	r := results[1]
+	joined := strings.Join(r.Problems, "; ")
+	if !strings.Contains(joined, "DOWN does not restore the previous schema") {
+		t.Errorf("DOWN problem not reported: %v", r.Problems)
+	}
+	if len(r.Changes) != 1 || r.Changes[0] != "table b was left behind" {
+		t.Errorf("unexpected changes: %v", r.Changes)
+	}
+}
+
+func TestRoundTrip_ShadowMustDiffer(t *testing.T) {
+	mg, migrationsDir := setupSQLiteMigrator(t, testMigrationFiles)
+	_ = mg.Close()
+
+	cfg := testConfig(t, map[string]interface{}{
+		"environments": map[string]interface{}{
+			"test": map[string]interface{}{
+				"database_url":        mg.env.DatabaseURL,
+				"shadow_database_url": mg.env.DatabaseURL,
+				"migrations_path":     migrationsDir,
+			},
+		},
+	})
+
+	_, err := RoundTrip(cfg, "test", nil)
+	if err == nil || !strings.Contains(err.Error(), "shadow_database_url") {
+		t.Errorf("expected shadow_database_url error, got: %v", err)
+	}
+}
+
+func TestSchemaChanges(t *testing.T) {
+	want := &schema.Schema{Tables: []schema.Table{
+		{Name: "a", Columns: []schema.Column{{Name: "id", Type: "INT"}}},
+		{Name: "b", Columns: []schema.Column{{Name: "id", Type: "INT"}}},
+	}}
+	got := &schema.Schema{
+		Tables: []schema.Table{{Name: "a", Columns: []schema.Column{{Name: "id", Type: "BIGINT"}}}},
+		Views:  []schema.View{{Name: "v", Definition: "SELECT 1"}},
+	}
+
+	changes := schemaChanges(want, got)
+	expected := []string{"table a differs", "table b is missing", "view v was left behind"}
+	if strings.Join(changes, "|") != strings.Join(expected, "|") {
+		t.Errorf("schemaChanges() = %v, want %v", changes, expected)
+	}
+}
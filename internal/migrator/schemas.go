@@ -0,0 +1,301 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	nurl "net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// SchemaStatus is the status of one schema of a schema-per-tenant
+// environment
+type SchemaStatus struct {
+	Schema  string `json:"schema" yaml:"schema"`
+	*Status `yaml:",inline"`
+}
+
+// NewForSchema creates a Migrator for one schema of a schema-per-tenant
+// environment. The schema must be one the environment selects.
+func NewForSchema(cfg *config.Config, envName, schemaName string) (*Migrator, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+	if !env.Schemas.IsSet() {
+		return nil, fmt.Errorf("environment %q does not configure schemas", envName)
+	}
+	schemas, missing, err := resolveSchemas(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schemas {
+		if s == schemaName {
+			mg, err := newSchemaMigrator(envName, env, s)
+			if err != nil {
+				return nil, err
+			}
+			mg.useConfigDir(cfg)
+			return mg, nil
+		}
+	}
+	if slices.Contains(missing, schemaName) {
+		return nil, fmt.Errorf("environment %q: schema %q does not exist yet; 'janus up' creates it", envName, schemaName)
+	}
+	return nil, fmt.Errorf("environment %q: schema %q not found (available: %v)", envName, schemaName, schemas)
+}
+
+// CreateSchemas creates the schemas an environment lists that do not exist
+// yet, limited to names when given. Opening an environment leaves missing
+// schemas alone, so commands that apply migrations call it first.
+func CreateSchemas(cfg *config.Config, envName string, names ...string) error {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return err
+	}
+	if len(env.Schemas.List) == 0 {
+		return nil
+	}
+
+	db, _, err := openDB(env.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, s := range env.Schemas.List {
+		if len(names) > 0 && !slices.Contains(names, s) {
+			continue
+		}
+		if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + quoteIdent(s)); err != nil {
+			return fmt.Errorf("create schema %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// newEnvMigrator creates the Migrator for an environment: a single database,
+// or one child per schema when the environment configures schemas
+func newEnvMigrator(envName string, env config.Environment) (*Migrator, error) {
+	if env.Targets.IsSet() {
+		return nil, fmt.Errorf("environment %q defines targets: %w", envName, ErrFleet)
+	}
+	if !env.Schemas.IsSet() {
+		return newMigrator(envName, env)
+	}
+
+	schemas, missing, err := resolveSchemas(env)
+	if err != nil {
+		return nil, err
+	}
+	srcDriver, err := singlefile.NewWithPath(env.MigrationsPath)
+	if err != nil {
+		return nil, fmt.Errorf("source driver: %w", err)
+	}
+	mg := &Migrator{env: env, envName: envName, sourceDriver: srcDriver, schemas: []*Migrator{}, missing: missing}
+	for _, s := range schemas {
+		child, err := newSchemaMigrator(envName, env, s)
+		if err != nil {
+			_ = mg.Close()
+			return nil, err
+		}
+		mg.schemas = append(mg.schemas, child)
+	}
+	return mg, nil
+}
+
+// newSchemaMigrator creates a Migrator whose connection has search_path set
+// to one schema, so migrations and the version table live in that schema
+func newSchemaMigrator(envName string, env config.Environment, schemaName string) (*Migrator, error) {
+	url, err := schemaURL(env.DatabaseURL, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	env.DatabaseURL = url
+	env.Schemas = config.Schemas{}
+	mg, err := newMigrator(envName, env)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", schemaName, err)
+	}
+	mg.schema = schemaName
+	return mg, nil
+}
+
+// resolveSchemas returns the existing schemas an environment migrates, in
+// name order, and the listed ones that do not exist yet. A pattern only
+// matches existing schemas.
+func resolveSchemas(env config.Environment) (schemas, missing []string, err error) {
+	db, _, err := openDB(env.DatabaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	rows, err := db.Query("SELECT schema_name FROM information_schema.schemata")
+	if err != nil {
+		return nil, nil, fmt.Errorf("list schemas: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, nil, fmt.Errorf("list schemas: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("list schemas: %w", err)
+	}
+
+	if len(env.Schemas.List) > 0 {
+		for _, s := range env.Schemas.List {
+			if slices.Contains(names, s) {
+				schemas = append(schemas, s)
+			} else {
+				missing = append(missing, s)
+			}
+		}
+		sort.Strings(schemas)
+		sort.Strings(missing)
+		return schemas, missing, nil
+	}
+
+	schemas = matchSchemas(names, env.Schemas.Pattern)
+	if len(schemas) == 0 {
+		return nil, nil, fmt.Errorf("no schema matches %q", env.Schemas.Pattern)
+	}
+	return schemas, nil, nil
+}
+
+// matchSchemas filters schema names by pattern, leaving out system schemas
+func matchSchemas(names []string, pattern string) []string {
+	var matched []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "pg_") || name == "information_schema" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// schemaURL sets search_path on a Postgres URL, replacing any set already
+func schemaURL(url, schemaName string) (string, error) {
+	u, err := nurl.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("parse database_url: %w", err)
+	}
+	q := u.Query()
+	q.Set("search_path", quoteIdent(schemaName))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// quoteIdent quotes a Postgres identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Schemas returns one Migrator per schema for a schema-per-tenant
+// environment, in name order, and nil otherwise
+func (mg *Migrator) Schemas() []*Migrator {
+	return mg.schemas
+}
+
+// SchemaName returns the schema a Migrator is bound to ("" if none)
+func (mg *Migrator) SchemaName() string {
+	return mg.schema
+}
+
+// SchemaStatuses returns the status of each schema. A listed schema that
+// does not exist yet has every migration pending.
+func (mg *Migrator) SchemaStatuses() ([]SchemaStatus, error) {
+	statuses := make([]SchemaStatus, 0, len(mg.schemas)+len(mg.missing))
+	for _, child := range mg.schemas {
+		status, err := child.Status()
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", child.schema, err)
+		}
+		statuses = append(statuses, SchemaStatus{Schema: child.schema, Status: status})
+	}
+	for _, name := range mg.missing {
+		pending, applied, total := mg.countMigrations(0)
+		statuses = append(statuses, SchemaStatus{Schema: name, Status: &Status{Pending: pending, Applied: applied, Total: total}})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Schema < statuses[j].Schema })
+	return statuses, nil
+}
+
+// schemasStatus combines the status of every schema: the lowest version,
+// dirty if any schema is, and counts summed across schemas
+func (mg *Migrator) schemasStatus() (*Status, error) {
+	statuses, err := mg.SchemaStatuses()
+	if err != nil {
+		return nil, err
+	}
+	combined := &Status{}
+	for i, s := range statuses {
+		if i == 0 || s.Version < combined.Version {
+			combined.Version = s.Version
+		}
+		combined.Dirty = combined.Dirty || s.Dirty
+		combined.Pending += s.Pending
+		combined.Applied += s.Applied
+		combined.Total += s.Total
+	}
+	return combined, nil
+}
+
+// eachSchema runs op for every schema in order, stopping at the first error
+// or when the migrator was stopped. It returns migrate.ErrNoChange only if
+// no schema changed.
+func (mg *Migrator) eachSchema(op func(*Migrator) error) error {
+	changed := false
+	for _, child := range mg.schemas {
+		if mg.Stopped() {
+			break
+		}
+		err := op(child)
+		if errors.Is(err, migrate.ErrNoChange) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", child.schema, err)
+		}
+		changed = true
+	}
+	if !changed && !mg.Stopped() {
+		return migrate.ErrNoChange
+	}
+	return nil
+}
+
+// requireCreated rejects migrating an environment whose listed schemas do
+// not all exist, which would silently leave the missing ones out
+func (mg *Migrator) requireCreated() error {
+	if len(mg.missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("environment %q: schemas %v do not exist; create them with CreateSchemas", mg.envName, mg.missing)
+}
+
+// requireSingle rejects operations that need one schema on an environment
+// that migrates several
+func (mg *Migrator) requireSingle() error {
+	if mg.schemas == nil {
+		return nil
+	}
+	return fmt.Errorf("environment %q migrates %d schemas; choose one with --schema", mg.envName, len(mg.schemas))
+}
@@ -0,0 +1,179 @@
+package migrator
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// setupSchemaMigrator builds a schema-per-tenant migrator whose "schemas"
+// are SQLite databases, since the tests have no Postgres server
+func setupSchemaMigrator(t *testing.T, schemas ...string) *Migrator {
+	t.Helper()
+
+	_, migrationsDir := setupSQLiteMigrator(t, testMigrationFiles)
+	env := config.Environment{MigrationsPath: migrationsDir}
+
+	src, err := singlefile.NewWithPath(migrationsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg := &Migrator{env: env, envName: "test", sourceDriver: src, schemas: []*Migrator{}}
+	for _, s := range schemas {
+		childEnv := env
+		childEnv.DatabaseURL = "sqlite3://" + filepath.Join(t.TempDir(), s+".db")
+		child, err := newMigrator("test", childEnv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child.schema = s
+		mg.schemas = append(mg.schemas, child)
+	}
+	t.Cleanup(func() { _ = mg.Close() })
+	return mg
+}
+
+func TestSchemas_UpRunsPerSchema(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a", "tenant_b")
+
+	if err := mg.schemas[0].Up(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Up(0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+
+	statuses, err := mg.SchemaStatuses()
+	if err != nil {
+		t.Fatalf("SchemaStatuses() error: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Version != 3 || s.Pending != 0 {
+			t.Errorf("schema %s: unexpected status %+v", s.Schema, s.Status)
+		}
+	}
+
+	if err := mg.Up(0); !errors.Is(err, migrate.ErrNoChange) {
+		t.Errorf("expected ErrNoChange when every schema is current, got: %v", err)
+	}
+}
+
+func TestSchemas_CombinedStatus(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a", "tenant_b")
+
+	if err := mg.schemas[0].Up(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.schemas[1].Up(1); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if status.Version != 1 || status.Applied != 4 || status.Pending != 2 || status.Total != 6 {
+		t.Errorf("unexpected combined status: %+v", status)
+	}
+
+	if err := mg.Down(1); err != nil {
+		t.Fatalf("Down() error: %v", err)
+	}
+	statuses, _ := mg.SchemaStatuses()
+	if statuses[0].Version != 2 || statuses[1].Version != 0 {
+		t.Errorf("Down should roll back each schema: %+v, %+v", statuses[0].Status, statuses[1].Status)
+	}
+}
+
+func TestSchemas_MissingSchemaIsPending(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a")
+	mg.missing = []string{"tenant_b"}
+	if err := mg.schemas[0].Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := mg.SchemaStatuses()
+	if err != nil {
+		t.Fatalf("SchemaStatuses() error: %v", err)
+	}
+	if len(statuses) != 2 || statuses[1].Schema != "tenant_b" || statuses[1].Version != 0 || statuses[1].Pending != 3 {
+		t.Errorf("a missing schema should have every migration pending: %+v", statuses)
+	}
+
+	if err := mg.Up(0); err == nil || !strings.Contains(err.Error(), "tenant_b") {
+		t.Errorf("Up() should refuse to leave a missing schema out, got: %v", err)
+	}
+	if err := mg.Down(1); err != nil {
+		t.Errorf("Down() should skip a missing schema: %v", err)
+	}
+}
+
+func TestSchemas_RequireSingle(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a", "tenant_b")
+
+	if _, err := mg.Plan(3); err == nil || !strings.Contains(err.Error(), "--schema") {
+		t.Errorf("Plan() should require one schema, got: %v", err)
+	}
+	if _, err := mg.DirtyState(); err == nil || !strings.Contains(err.Error(), "2 schemas") {
+		t.Errorf("DirtyState() should require one schema, got: %v", err)
+	}
+	if _, err := mg.RedoMigrations(1); err == nil {
+		t.Error("RedoMigrations() should require one schema")
+	}
+
+	if _, err := mg.schemas[0].Plan(3); err != nil {
+		t.Errorf("a schema's own migrator should plan: %v", err)
+	}
+}
+
+func TestSchemas_EventsNameSchema(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a", "tenant_b")
+
+	var events []Event
+	mg.OnEvent(func(e Event) { events = append(events, e) })
+	if err := mg.Up(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 4 || events[0].Schema != "tenant_a" || events[3].Schema != "tenant_b" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestNewForSchema_RequiresSchemas(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	if _, err := NewForSchema(envConfig(mg), "test", "tenant_a"); err == nil || !strings.Contains(err.Error(), "does not configure schemas") {
+		t.Errorf("expected error for environment without schemas, got: %v", err)
+	}
+}
+
+func TestMatchSchemas(t *testing.T) {
+	names := []string{"public", "tenant_b", "pg_catalog", "information_schema", "tenant_a", "tenants_archive"}
+
+	if got := strings.Join(matchSchemas(names, "tenant_*"), ","); got != "tenant_a,tenant_b" {
+		t.Errorf("matchSchemas(tenant_*) = %s", got)
+	}
+	if got := strings.Join(matchSchemas(names, "*"), ","); got != "public,tenant_a,tenant_b,tenants_archive" {
+		t.Errorf("matchSchemas(*) should leave out system schemas: %s", got)
+	}
+}
+
+func TestSchemaURL(t *testing.T) {
+	got, err := schemaURL("postgres://u:p@localhost:5432/app?sslmode=disable&search_path=public&x-migrations-table=versions", `Tenant"A`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urlParam(got, "search_path") != `"Tenant""A"` {
+		t.Errorf("search_path not set: %s", got)
+	}
+	if urlParam(got, "sslmode") != "disable" || migrationsTable(got) != "versions" {
+		t.Errorf("other parameters should be kept: %s", got)
+	}
+}
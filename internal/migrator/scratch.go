@@ -0,0 +1,49 @@
+package migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// scratchDatabase returns the URL of the environment's scratch database:
+// shadow_database_url, or a temporary SQLite file that cleanup removes.
+// The database is wiped before it is returned.
+func scratchDatabase(env config.Environment) (url string, cleanup func(), err error) {
+	cleanup = func() {}
+	url = env.ShadowDatabaseURL
+	if url == "" {
+		dir, err := os.MkdirTemp("", "janus-scratch-")
+		if err != nil {
+			return "", nil, fmt.Errorf("create scratch database: %w", err)
+		}
+		cleanup = func() { _ = os.RemoveAll(dir) }
+		url = "sqlite3://" + filepath.Join(dir, "shadow.db")
+	}
+	if url == env.DatabaseURL {
+		return "", nil, fmt.Errorf("shadow_database_url must not be the environment's database_url")
+	}
+
+	if err := wipeDatabase(url); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("wipe scratch database: %w", err)
+	}
+	return url, cleanup, nil
+}
+
+// wipeDatabase drops every table in the database
+func wipeDatabase(url string) error {
+	driver, err := database.Open(url)
+	if err != nil {
+		return err
+	}
+	dropErr := driver.Drop()
+	if err := driver.Close(); dropErr == nil {
+		dropErr = err
+	}
+	return dropErr
+}
@@ -0,0 +1,100 @@
+package migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/schema"
+)
+
+// Schema introspects the environment's database, leaving out the
+// migrations table. A schema-per-tenant environment is represented by its
+// first schema.
+func (mg *Migrator) Schema() (*schema.Schema, error) {
+	if len(mg.schemas) > 0 {
+		return mg.schemas[0].Schema()
+	}
+	return inspectURL(mg.env.DatabaseURL)
+}
+
+// InspectSchema introspects an environment's database without setting up
+// a migrate instance, so it neither creates the migrations table nor waits
+// for a running migration's lock
+func InspectSchema(cfg *config.Config, envName string) (*schema.Schema, string, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, "", err
+	}
+	s, err := inspectURL(env.DatabaseURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, databaseDialect(env.DatabaseURL), nil
+}
+
+func inspectURL(url string) (*schema.Schema, error) {
+	db, dialect, err := openDB(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	s, err := schema.Inspect(db, dialect, migrationsTable(url))
+	if err != nil {
+		return nil, fmt.Errorf("inspect schema: %w", err)
+	}
+	return s, nil
+}
+
+// SchemaFile returns the configured schema snapshot path ("" if none)
+func (mg *Migrator) SchemaFile() string {
+	return mg.env.SchemaFile
+}
+
+// WriteSchemaSnapshot writes the normalized schema to the environment's
+// schema_file. It does nothing when no schema_file is configured.
+func (mg *Migrator) WriteSchemaSnapshot() error {
+	if mg.env.SchemaFile == "" {
+		return nil
+	}
+
+	// A schema-per-tenant environment is represented by its first schema,
+	// as in Schema
+	src := mg
+	if len(mg.schemas) > 0 {
+		src = mg.schemas[0]
+	}
+	s, err := src.Schema()
+	if err != nil {
+		return err
+	}
+	version, _, err := src.m.Version()
+	if err != nil {
+		version = 0
+	}
+
+	content := renderSnapshot(s, version)
+	if dir := filepath.Dir(mg.env.SchemaFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("write schema snapshot: %w", err)
+		}
+	}
+	if err := os.WriteFile(mg.env.SchemaFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("write schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// renderSnapshot renders the schema file contents. Only the schema and the
+// version go in, so the file changes exactly when a migration changes them.
+func renderSnapshot(s *schema.Schema, version uint) string {
+	header := fmt.Sprintf("-- Schema snapshot at migration version %d.\n"+
+		"-- Generated by janus; do not edit by hand.\n", version)
+	body := s.SQL()
+	if body == "" {
+		return header
+	}
+	return header + "\n" + body
+}
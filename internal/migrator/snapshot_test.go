@@ -0,0 +1,76 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSchemaSnapshot(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+	mg.env.SchemaFile = filepath.Join(t.TempDir(), "db", "schema.sql")
+
+	if err := mg.Up(2); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	if err := mg.WriteSchemaSnapshot(); err != nil {
+		t.Fatalf("WriteSchemaSnapshot() error: %v", err)
+	}
+
+	got, err := os.ReadFile(mg.env.SchemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `-- Schema snapshot at migration version 2.
+-- Generated by janus; do not edit by hand.
+
+CREATE TABLE a (
+  id INT
+);
+
+CREATE TABLE b (
+  id INT
+);
+`
+	if string(got) != want {
+		t.Errorf("snapshot =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteSchemaSnapshot_Schemas(t *testing.T) {
+	mg := setupSchemaMigrator(t, "tenant_a", "tenant_b")
+	mg.env.SchemaFile = filepath.Join(t.TempDir(), "schema.sql")
+
+	if err := mg.Up(0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	if err := mg.WriteSchemaSnapshot(); err != nil {
+		t.Fatalf("WriteSchemaSnapshot() error: %v", err)
+	}
+
+	got, err := os.ReadFile(mg.env.SchemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(got), "-- Schema snapshot at migration version 3.\n") {
+		t.Errorf("snapshot should be taken from the first schema:\n%s", got)
+	}
+}
+
+func TestWriteSchemaSnapshot_NotConfigured(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	dir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	_ = os.Chdir(dir)
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	if err := mg.WriteSchemaSnapshot(); err != nil {
+		t.Fatalf("WriteSchemaSnapshot() error: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no file written, found %d", len(entries))
+	}
+}
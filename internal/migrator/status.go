@@ -8,15 +8,20 @@ import (
 
 // Status represents migration status info
 type Status struct {
-	Version uint
-	Dirty   bool
-	Pending int
-	Applied int
-	Total   int
+	Version uint `json:"version" yaml:"version"`
+	Dirty   bool `json:"dirty" yaml:"dirty"`
+	Pending int  `json:"pending" yaml:"pending"`
+	Applied int  `json:"applied" yaml:"applied"`
+	Total   int  `json:"total" yaml:"total"`
 }
 
 // Status returns current migration status
+// For a schema-per-tenant environment the counts are summed across schemas
+// and Version is the lowest schema version; see SchemaStatuses.
 func (mg *Migrator) Status() (*Status, error) {
+	if mg.schemas != nil {
+		return mg.schemasStatus()
+	}
 	version, dirty, err := mg.m.Version()
 	if err != nil && err != migrate.ErrNilVersion {
 		return nil, err
@@ -58,9 +63,9 @@ func (mg *Migrator) countMigrations(currentVersion uint) (pending, applied, tota
 
 // MigrationInfo represents a single migration entry
 type MigrationInfo struct {
-	Version uint
-	Name    string
-	Applied bool
+	Version uint   `json:"version" yaml:"version"`
+	Name    string `json:"name" yaml:"name"`
+	Applied bool   `json:"applied" yaml:"applied"`
 }
 
 // GetMigrationList returns list of migrations with applied status
@@ -81,3 +86,12 @@ func (mg *Migrator) GetMigrationList(currentVersion uint) []MigrationInfo {
 
 	return list
 }
+
+// MigrationsBetween lists migrations with from < version <= to, ascending
+func (mg *Migrator) MigrationsBetween(from, to uint) []MigrationInfo {
+	var list []MigrationInfo
+	for _, pm := range mg.plannedBetween(from, to, false) {
+		list = append(list, MigrationInfo{Version: pm.Version, Name: pm.Name, Applied: true})
+	}
+	return list
+}
@@ -0,0 +1,139 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// EnvStatus is the status of one environment in a cross-environment view
+type EnvStatus struct {
+	Environment string  `json:"env" yaml:"env"`
+	Status      *Status `json:"status,omitempty" yaml:"status,omitempty"`
+	Error       string  `json:"error,omitempty" yaml:"error,omitempty"`
+	// Behind names the previous environment in promotion_order when this
+	// one is at a lower version
+	Behind string `json:"behind,omitempty" yaml:"behind,omitempty"`
+	// Fleet is set, with no status, for an environment that defines
+	// targets: each target has its own version
+	Fleet bool `json:"fleet,omitempty" yaml:"fleet,omitempty"`
+}
+
+// StatusAll reads the status of every environment of cfg, at most
+// concurrency at a time, giving each timeout to answer once it gets a slot.
+// A connection abandoned at its timeout keeps its slot until it returns.
+// Results follow promotion_order, then the remaining environments by name.
+// Fleet environments are marked as such rather than read.
+func StatusAll(cfg *config.Config, concurrency int, timeout time.Duration) []EnvStatus {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	names := envOrder(cfg)
+	results := make([]EnvStatus, len(names))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			results[i] = EnvStatus{Environment: name}
+			status, err := statusWithTimeout(cfg, name, timeout, sem)
+			if errors.Is(err, ErrFleet) {
+				results[i].Fleet = true
+				return
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Status = status
+		}(i, name)
+	}
+	wg.Wait()
+
+	markBehind(results, len(cfg.PromotionOrder))
+	return results
+}
+
+// envOrder lists environments in promotion order, then the rest by name
+func envOrder(cfg *config.Config) []string {
+	names := append([]string{}, cfg.PromotionOrder...)
+	ordered := make(map[string]bool, len(names))
+	for _, name := range names {
+		ordered[name] = true
+	}
+
+	var rest []string
+	for name := range cfg.Environments {
+		if !ordered[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// markBehind flags environments among the first n (the promotion order)
+// whose version is lower than the environment directly before them
+func markBehind(results []EnvStatus, n int) {
+	for i := 1; i < n && i < len(results); i++ {
+		prev, cur := results[i-1], results[i]
+		if prev.Status == nil || cur.Status == nil {
+			continue
+		}
+		if cur.Status.Version < prev.Status.Version {
+			results[i].Behind = prev.Environment
+		}
+	}
+}
+
+// statusWithTimeout reads an environment's status in a slot of sem, giving
+// up after timeout. A connection still pending at the timeout is closed once
+// it returns.
+func statusWithTimeout(cfg *config.Config, envName string, timeout time.Duration, sem chan struct{}) (*Status, error) {
+	env, err := cfg.Env(envName)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(timeout, sem, func() (*Status, error) {
+		mg, err := newEnvMigrator(envName, env)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = mg.Close() }()
+		return mg.Status()
+	})
+}
+
+// withTimeout runs fn once a slot of sem is free, returning an error if it
+// has not finished after timeout. fn keeps running in the background in
+// that case, and holds its slot until it returns so abandoned calls still
+// count against the concurrency limit.
+func withTimeout(timeout time.Duration, sem chan struct{}, fn func() (*Status, error)) (*Status, error) {
+	type result struct {
+		status *Status
+		err    error
+	}
+	sem <- struct{}{}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-sem }()
+		status, err := fn()
+		done <- result{status: status, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.status, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
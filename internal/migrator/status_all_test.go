@@ -0,0 +1,154 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesc1802/janus/internal/config"
+)
+
+// setupEnvs configures SQLite environments sharing testMigrationFiles and
+// applies the given number of migrations to each
+func setupEnvs(t *testing.T, applied map[string]int, promotion []string) *config.Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	_ = os.MkdirAll(migrationsDir, 0755)
+	for name, content := range testMigrationFiles {
+		_ = os.WriteFile(filepath.Join(migrationsDir, name), []byte(content), 0644)
+	}
+
+	envs := map[string]interface{}{}
+	for name := range applied {
+		envs[name] = map[string]interface{}{
+			"database_url":    "sqlite3://" + filepath.Join(dir, name+".db"),
+			"migrations_path": migrationsDir,
+		}
+	}
+	cfg := testConfig(t, map[string]interface{}{
+		"environments":    envs,
+		"promotion_order": promotion,
+	})
+
+	for name, n := range applied {
+		if n == 0 {
+			continue
+		}
+		mg, err := New(cfg, name)
+		if err != nil {
+			t.Fatalf("New(%s) error: %v", name, err)
+		}
+		if err := mg.Up(n); err != nil {
+			t.Fatalf("Up(%s) error: %v", name, err)
+		}
+		_ = mg.Close()
+	}
+	return cfg
+}
+
+func TestStatusAll(t *testing.T) {
+	cfg := setupEnvs(t, map[string]int{"dev": 3, "staging": 1, "prod": 1, "adhoc": 0}, []string{"dev", "staging", "prod"})
+
+	results := StatusAll(cfg, 2, 10*time.Second)
+
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Environment)
+		if r.Error != "" {
+			t.Errorf("%s: unexpected error: %s", r.Environment, r.Error)
+		}
+	}
+	if strings.Join(names, ",") != "dev,staging,prod,adhoc" {
+		t.Fatalf("unexpected order: %v", names)
+	}
+
+	if results[0].Status.Version != 3 || results[1].Status.Version != 1 || results[1].Status.Pending != 2 {
+		t.Errorf("unexpected statuses: %+v, %+v", results[0].Status, results[1].Status)
+	}
+	if results[1].Behind != "dev" {
+		t.Errorf("staging should be behind dev, got %q", results[1].Behind)
+	}
+	if results[2].Behind != "" || results[3].Behind != "" {
+		t.Errorf("only staging should be behind: %+v", results)
+	}
+}
+
+func TestStatusAll_ReportsErrors(t *testing.T) {
+	cfg := setupEnvs(t, map[string]int{"dev": 1}, nil)
+	cfg.Environments["broken"] = config.Environment{
+		DatabaseURL:    "sqlite3://" + filepath.Join(t.TempDir(), "missing", "dir", "x.db"),
+		MigrationsPath: t.TempDir(),
+	}
+
+	results := StatusAll(cfg, 4, 10*time.Second)
+	if len(results) != 2 || results[0].Environment != "broken" || results[0].Error == "" || results[0].Status != nil {
+		t.Errorf("expected broken environment to report an error: %+v", results)
+	}
+	if results[1].Error != "" || results[1].Status == nil {
+		t.Errorf("dev should still report status: %+v", results[1])
+	}
+}
+
+func TestStatusAll_MarksFleets(t *testing.T) {
+	cfg := setupEnvs(t, map[string]int{"dev": 1}, nil)
+	cfg.Environments["tenants"] = config.Environment{
+		MigrationsPath: t.TempDir(),
+		Targets:        config.Targets{List: []config.Target{{Name: "a", DatabaseURL: "sqlite3://" + filepath.Join(t.TempDir(), "a.db")}}},
+	}
+
+	results := StatusAll(cfg, 4, 10*time.Second)
+	if len(results) != 2 || results[1].Environment != "tenants" || !results[1].Fleet || results[1].Error != "" || results[1].Status != nil {
+		t.Errorf("expected tenants to be marked as a fleet: %+v", results)
+	}
+	if results[0].Fleet || results[0].Status == nil {
+		t.Errorf("dev should report status: %+v", results[0])
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	sem := make(chan struct{}, 1)
+	status, err := withTimeout(time.Second, sem, func() (*Status, error) { return &Status{Version: 2}, nil })
+	if err != nil || status.Version != 2 {
+		t.Errorf("withTimeout() = %+v, %v", status, err)
+	}
+
+	release := make(chan struct{})
+	_, err = withTimeout(10*time.Millisecond, sem, func() (*Status, error) {
+		<-release
+		return &Status{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+
+	// The abandoned call keeps its slot until it returns
+	select {
+	case sem <- struct{}{}:
+		t.Fatal("slot released while the timed out call is still running")
+	default:
+	}
+	close(release)
+	select {
+	case sem <- struct{}{}:
+	case <-time.After(time.Second):
+		t.Error("slot not released after the timed out call returned")
+	}
+}
+
+func TestMarkBehind(t *testing.T) {
+	results := []EnvStatus{
+		{Environment: "dev", Status: &Status{Version: 5}},
+		{Environment: "staging", Status: &Status{Version: 5}},
+		{Environment: "prod", Status: &Status{Version: 3}},
+		{Environment: "other", Status: &Status{Version: 1}},
+	}
+	markBehind(results, 3)
+
+	if results[1].Behind != "" || results[2].Behind != "staging" || results[3].Behind != "" {
+		t.Errorf("unexpected behind flags: %+v", results)
+	}
+}
@@ -47,3 +47,12 @@ func TestMigrationInfoStruct(t *testing.T) {
 		t.Error("Applied = false, want true")
 	}
 }
+
+func TestMigrationsBetween(t *testing.T) {
+	mg, _ := setupSQLiteMigrator(t, testMigrationFiles)
+
+	list := mg.MigrationsBetween(1, 3)
+	if len(list) != 2 || list[0].Version != 2 || list[1].Name != "c" {
+		t.Errorf("MigrationsBetween(1, 3) = %+v", list)
+	}
+}
@@ -0,0 +1,238 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/schema"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+// Supported database dialects, derived from the database URL scheme
+const (
+	DialectPostgres = schema.DialectPostgres
+	DialectMySQL    = schema.DialectMySQL
+	DialectSQLite   = schema.DialectSQLite
+)
+
+// databaseDialect returns the dialect for a database URL ("" if unknown)
+func databaseDialect(url string) string {
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		return ""
+	}
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return DialectPostgres
+	case "mysql":
+		return DialectMySQL
+	case "sqlite3", "sqlite":
+		return DialectSQLite
+	}
+	return ""
+}
+
+// errMySQLStatementTimeout explains why statement_timeout is refused on MySQL
+var errMySQLStatementTimeout = errors.New("statement_timeout is not supported on MySQL: " +
+	"max_execution_time only limits SELECT statements, so migrations would run without a limit (use lock_timeout)")
+
+// sessionDriver wraps the database driver and sets the session timeouts for
+// each migration just before its body runs, as statements of their own on
+// the migration's connection. Prefixing the body instead would turn it into
+// a multi-statement transaction on Postgres, where CREATE INDEX CONCURRENTLY
+// fails. Every migration sets both timeouts (not only overridden ones) so a
+// per-file override never leaks into the migrations that follow it.
+type sessionDriver struct {
+	database.Driver
+	dialect    string
+	env        config.Environment
+	migrations map[uint]singlefile.Migration
+	// pending is the version of the migration about to run, 0 if none
+	pending uint
+}
+
+// newSessionDriver wraps drv when timeouts are configured and supported.
+// Returns drv unchanged otherwise, and an error for a statement_timeout on
+// MySQL.
+func newSessionDriver(drv database.Driver, src *singlefile.Driver, env config.Environment, dialect string) (database.Driver, error) {
+	if err := checkTimeouts(src, env, dialect); err != nil {
+		return nil, err
+	}
+	if dialect != DialectPostgres && dialect != DialectMySQL {
+		return drv, nil
+	}
+
+	enabled := env.StatementTimeout > 0 || env.LockTimeout > 0
+	for _, m := range src.GetMigrations() {
+		if m.StatementTimeout > 0 || m.LockTimeout > 0 {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return drv, nil
+	}
+
+	return &sessionDriver{
+		Driver:     drv,
+		dialect:    dialect,
+		env:        env,
+		migrations: src.GetMigrations(),
+	}, nil
+}
+
+// checkTimeouts rejects statement timeouts on MySQL, set for the
+// environment or in a migration file
+func checkTimeouts(src *singlefile.Driver, env config.Environment, dialect string) error {
+	if dialect != DialectMySQL {
+		return nil
+	}
+	if env.StatementTimeout > 0 {
+		return errMySQLStatementTimeout
+	}
+	for _, v := range src.GetVersions() {
+		if m := src.GetMigrations()[v]; m.StatementTimeout > 0 {
+			return fmt.Errorf("migration %06d: %w", v, errMySQLStatementTimeout)
+		}
+	}
+	return nil
+}
+
+// SetVersion notes which migration runs next when golang-migrate marks the
+// database dirty before running it: the target version going up, the
+// current one going down
+func (d *sessionDriver) SetVersion(version int, dirty bool) error {
+	if dirty {
+		from, _, err := d.Driver.Version()
+		if err != nil {
+			return err
+		}
+		if version > from {
+			d.pending = uint(version)
+		} else {
+			d.pending = uint(from)
+		}
+	}
+	return d.Driver.SetVersion(version, dirty)
+}
+
+// Run sets the session timeouts of the pending migration, then runs it
+func (d *sessionDriver) Run(migration io.Reader) error {
+	if d.pending != 0 {
+		statement, lock := d.timeouts(d.pending)
+		d.pending = 0
+		for _, stmt := range timeoutStatements(d.dialect, statement, lock) {
+			if err := d.Driver.Run(strings.NewReader(stmt)); err != nil {
+				return fmt.Errorf("set session timeouts: %w", err)
+			}
+		}
+	}
+	return d.Driver.Run(migration)
+}
+
+// timeouts returns the effective timeouts for a migration version
+func (d *sessionDriver) timeouts(version uint) (statement, lock time.Duration) {
+	statement, lock = d.env.StatementTimeout, d.env.LockTimeout
+	if m, ok := d.migrations[version]; ok {
+		if m.StatementTimeout > 0 {
+			statement = m.StatementTimeout
+		}
+		if m.LockTimeout > 0 {
+			lock = m.LockTimeout
+		}
+	}
+	return statement, lock
+}
+
+// timeoutStatements renders the SET statements for a dialect.
+// A zero duration resets the setting to the server default.
+func timeoutStatements(dialect string, statement, lock time.Duration) []string {
+	switch dialect {
+	case DialectPostgres:
+		return []string{
+			"SET statement_timeout = " + millisOrDefault(statement),
+			"SET lock_timeout = " + millisOrDefault(lock),
+		}
+	case DialectMySQL:
+		// statement is always 0: checkTimeouts refuses it on MySQL
+		return []string{
+			"SET SESSION lock_wait_timeout = " + secondsOrDefault(lock),
+			"SET SESSION innodb_lock_wait_timeout = " + secondsOrDefault(lock),
+		}
+	}
+	return nil
+}
+
+func millisOrDefault(d time.Duration) string {
+	if d <= 0 {
+		return "DEFAULT"
+	}
+	ms := d.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("%d", ms)
+}
+
+func secondsOrDefault(d time.Duration) string {
+	if d <= 0 {
+		return "DEFAULT"
+	}
+	secs := int64((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d", secs)
+}
+
+// TimeoutError reports a migration cancelled by a statement or lock timeout
+type TimeoutError struct {
+	// Kind is "statement_timeout" or "lock_timeout"
+	Kind string
+	// Version is the (dirty) version recorded after the failure
+	Version uint
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s exceeded; database marked dirty at version %d (see 'janus status'): %v",
+		e.Kind, e.Version, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// timeoutMessages maps driver error text to the timeout that caused it
+var timeoutMessages = []struct {
+	text string
+	kind string
+}{
+	{"canceling statement due to statement timeout", "statement_timeout"},
+	{"canceling statement due to lock timeout", "lock_timeout"},
+	{"maximum statement execution time exceeded", "statement_timeout"},
+	{"Lock wait timeout exceeded", "lock_timeout"},
+}
+
+// classifyError converts driver timeout errors into a *TimeoutError.
+// Other errors are returned unchanged.
+func (mg *Migrator) classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return err
+	}
+
+	msg := err.Error()
+	for _, tm := range timeoutMessages {
+		if strings.Contains(msg, tm.text) {
+			version, _, _ := mg.m.Version()
+			return &TimeoutError{Kind: tm.kind, Version: version, Err: err}
+		}
+	}
+	return err
+}
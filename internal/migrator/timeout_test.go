@@ -0,0 +1,173 @@
+package migrator
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/cesc1802/janus/internal/config"
+	"github.com/cesc1802/janus/internal/source/singlefile"
+)
+
+func TestDatabaseDialect(t *testing.T) {
+	tests := map[string]string{
+		"postgres://u:p@localhost:5432/db":   DialectPostgres,
+		"postgresql://localhost/db":          DialectPostgres,
+		"mysql://u:p@tcp(localhost:3306)/db": DialectMySQL,
+		"sqlite3:///tmp/test.db":             DialectSQLite,
+		"cockroach://localhost/db":           "",
+		"no-scheme":                          "",
+	}
+
+	for url, want := range tests {
+		if got := databaseDialect(url); got != want {
+			t.Errorf("databaseDialect(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestTimeoutStatements(t *testing.T) {
+	pg := strings.Join(timeoutStatements(DialectPostgres, 30*time.Second, 0), "; ")
+	if pg != "SET statement_timeout = 30000; SET lock_timeout = DEFAULT" {
+		t.Errorf("postgres statements = %q", pg)
+	}
+
+	my := strings.Join(timeoutStatements(DialectMySQL, 0, 1500*time.Millisecond), "; ")
+	if strings.Contains(my, "max_execution_time") || !strings.Contains(my, "lock_wait_timeout = 2") {
+		t.Errorf("mysql statements = %q", my)
+	}
+
+	if s := timeoutStatements(DialectSQLite, time.Second, time.Second); s != nil {
+		t.Errorf("sqlite should not get timeout statements, got %q", s)
+	}
+}
+
+func newTestSource(t *testing.T, files map[string]string) *singlefile.Driver {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := singlefile.NewWithPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src.(*singlefile.Driver)
+}
+
+// recordingDriver records the statements run through it
+type recordingDriver struct {
+	database.Driver
+	version int
+	ran     []string
+}
+
+func (d *recordingDriver) Version() (int, bool, error) {
+	return d.version, false, nil
+}
+
+func (d *recordingDriver) SetVersion(version int, dirty bool) error {
+	d.version = version
+	return nil
+}
+
+func (d *recordingDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	d.ran = append(d.ran, string(body))
+	return err
+}
+
+func TestSessionDriver_PerMigrationOverride(t *testing.T) {
+	src := newTestSource(t, map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);",
+		"000002_b.sql": "-- +migrate Timeout: lock=5s\n-- +migrate UP\nCREATE INDEX CONCURRENTLY i ON a(id);",
+	})
+	env := config.Environment{StatementTimeout: time.Minute}
+	rec := &recordingDriver{version: database.NilVersion}
+
+	drv, err := newSessionDriver(rec, src, env, DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As golang-migrate runs a migration: mark dirty, run the body, mark clean
+	run := func(target int, body string) {
+		if err := drv.SetVersion(target, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := drv.Run(strings.NewReader(body)); err != nil {
+			t.Fatal(err)
+		}
+		if err := drv.SetVersion(target, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run(1, "CREATE TABLE")
+	run(2, "CREATE INDEX CONCURRENTLY")
+	run(1, "DROP INDEX") // down from 2
+
+	want := []string{
+		"SET statement_timeout = 60000", "SET lock_timeout = DEFAULT", "CREATE TABLE",
+		"SET statement_timeout = 60000", "SET lock_timeout = 5000", "CREATE INDEX CONCURRENTLY",
+		"SET statement_timeout = 60000", "SET lock_timeout = 5000", "DROP INDEX",
+	}
+	if strings.Join(rec.ran, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran:\n%s\nwant:\n%s", strings.Join(rec.ran, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSessionDriver_DisabledWithoutTimeouts(t *testing.T) {
+	src := newTestSource(t, map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);",
+	})
+	rec := &recordingDriver{}
+
+	if drv, err := newSessionDriver(rec, src, config.Environment{}, DialectPostgres); err != nil || drv != rec {
+		t.Errorf("driver should not be wrapped when no timeouts are configured (err %v)", err)
+	}
+	env := config.Environment{LockTimeout: time.Second}
+	if drv, err := newSessionDriver(rec, src, env, DialectSQLite); err != nil || drv != rec {
+		t.Errorf("driver should not be wrapped for sqlite (err %v)", err)
+	}
+}
+
+func TestSessionDriver_MySQLStatementTimeout(t *testing.T) {
+	plain := newTestSource(t, map[string]string{
+		"000001_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);",
+	})
+	override := newTestSource(t, map[string]string{
+		"000001_a.sql": "-- +migrate Timeout: statement=1m\n-- +migrate UP\nCREATE TABLE a (id INT);",
+	})
+
+	if _, err := newSessionDriver(&recordingDriver{}, plain, config.Environment{StatementTimeout: time.Minute}, DialectMySQL); !errors.Is(err, errMySQLStatementTimeout) {
+		t.Errorf("environment statement_timeout on MySQL: err = %v", err)
+	}
+	_, err := newSessionDriver(&recordingDriver{}, override, config.Environment{}, DialectMySQL)
+	if !errors.Is(err, errMySQLStatementTimeout) || !strings.Contains(err.Error(), "000001") {
+		t.Errorf("migration statement timeout on MySQL: err = %v", err)
+	}
+	if _, err := newSessionDriver(&recordingDriver{}, plain, config.Environment{LockTimeout: time.Second}, DialectMySQL); err != nil {
+		t.Errorf("lock_timeout on MySQL should be accepted: %v", err)
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	cause := errors.New("pq: canceling statement due to lock timeout")
+	err := &TimeoutError{Kind: "lock_timeout", Version: 4, Err: cause}
+
+	if !errors.Is(err, cause) {
+		t.Error("TimeoutError should unwrap to the driver error")
+	}
+	if !strings.Contains(err.Error(), "lock_timeout exceeded") || !strings.Contains(err.Error(), "version 4") {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
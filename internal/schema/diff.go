@@ -0,0 +1,149 @@
+package schema
+
+import "sort"
+
+// Difference kinds, from the point of view of the schema being checked
+const (
+	// Missing objects exist only in the expected schema
+	Missing = "missing"
+	// Extra objects exist only in the actual schema
+	Extra = "extra"
+	// Changed objects exist in both with different definitions
+	Changed = "changed"
+)
+
+// Object types a Difference can refer to
+const (
+	ObjectTable      = "table"
+	ObjectColumn     = "column"
+	ObjectIndex      = "index"
+	ObjectConstraint = "constraint"
+	ObjectView       = "view"
+)
+
+// Difference is one object that differs between two schemas
+type Difference struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Object string `json:"object" yaml:"object"`
+	// Table is the owning table of a column, index or constraint
+	Table string `json:"table,omitempty" yaml:"table,omitempty"`
+	Name  string `json:"name" yaml:"name"`
+	// Expected and Actual are the object's definitions on each side
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty" yaml:"actual,omitempty"`
+}
+
+// QualifiedName returns table.name for objects owned by a table
+func (d Difference) QualifiedName() string {
+	if d.Table == "" {
+		return d.Name
+	}
+	return d.Table + "." + d.Name
+}
+
+// Diff compares actual against expected. Tables missing or extra as a whole
+// are reported once, not per column. Table differences come first in table
+// name order, then views.
+func Diff(expected, actual *Schema) []Difference {
+	var diffs []Difference
+
+	want, got := expected.tableIndex(), actual.tableIndex()
+	for _, name := range unionKeys(want, got) {
+		wi, inWant := want[name]
+		gi, inGot := got[name]
+		switch {
+		case !inGot:
+			diffs = append(diffs, Difference{Kind: Missing, Object: ObjectTable, Name: name})
+		case !inWant:
+			diffs = append(diffs, Difference{Kind: Extra, Object: ObjectTable, Name: name})
+		default:
+			diffs = append(diffs, diffTable(expected.Tables[wi], actual.Tables[gi])...)
+		}
+	}
+
+	diffs = append(diffs, diffObjects(ObjectView, "", viewDefs(expected.Views), viewDefs(actual.Views))...)
+	return diffs
+}
+
+func diffTable(want, got Table) []Difference {
+	var diffs []Difference
+	diffs = append(diffs, diffObjects(ObjectColumn, want.Name, columnDefs(want.Columns), columnDefs(got.Columns))...)
+	diffs = append(diffs, diffObjects(ObjectConstraint, want.Name, constraintDefs(want.Constraints), constraintDefs(got.Constraints))...)
+	diffs = append(diffs, diffObjects(ObjectIndex, want.Name, indexDefs(want.Name, want.Indexes), indexDefs(got.Name, got.Indexes))...)
+	return diffs
+}
+
+// diffObjects compares two name -> definition maps of one object type
+func diffObjects(object, table string, want, got map[string]string) []Difference {
+	var diffs []Difference
+	for _, name := range unionKeys(want, got) {
+		w, inWant := want[name]
+		g, inGot := got[name]
+		d := Difference{Object: object, Table: table, Name: name, Expected: w, Actual: g}
+		switch {
+		case !inGot:
+			d.Kind = Missing
+		case !inWant:
+			d.Kind = Extra
+		case w != g:
+			d.Kind = Changed
+		default:
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+func columnDefs(cols []Column) map[string]string {
+	defs := make(map[string]string, len(cols))
+	for _, c := range cols {
+		defs[c.Name] = columnDef(c)
+	}
+	return defs
+}
+
+// constraintDefs keys constraints by name, or by definition where the
+// database does not name them
+func constraintDefs(cons []Constraint) map[string]string {
+	defs := make(map[string]string, len(cons))
+	for _, c := range cons {
+		key := c.Name
+		if key == "" {
+			key = c.Definition
+		}
+		defs[key] = c.Definition
+	}
+	return defs
+}
+
+func indexDefs(table string, idxs []Index) map[string]string {
+	defs := make(map[string]string, len(idxs))
+	for _, idx := range idxs {
+		defs[idx.Name] = idx.SQL(table)
+	}
+	return defs
+}
+
+func viewDefs(views []View) map[string]string {
+	defs := make(map[string]string, len(views))
+	for _, v := range views {
+		defs[v.Name] = v.SQL()
+	}
+	return defs
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	expected := &Schema{
+		Tables: []Table{
+			{
+				Name: "orders",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "total", Type: "numeric(10,2)", Nullable: true},
+					{Name: "note", Type: "text", Nullable: true},
+				},
+				Indexes:     []Index{{Name: "idx_orders_total", Columns: []string{"total"}}},
+				Constraints: []Constraint{{Name: "orders_pkey", Type: PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+			},
+			{Name: "audit", Columns: []Column{{Name: "id", Type: "integer"}}},
+		},
+		Views: []View{{Name: "big_orders", Definition: "SELECT * FROM orders WHERE total > 100"}},
+	}
+	actual := &Schema{
+		Tables: []Table{
+			{
+				Name: "orders",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "total", Type: "numeric(12,2)", Nullable: true},
+				},
+				Indexes: []Index{
+					{Name: "idx_orders_total", Columns: []string{"total"}},
+					{Name: "idx_orders_tmp", Columns: []string{"id", "total"}},
+				},
+				Constraints: []Constraint{{Name: "orders_pkey", Type: PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+			},
+			{Name: "hotfix_backup", Columns: []Column{{Name: "id", Type: "integer"}}},
+		},
+	}
+
+	got := Diff(expected, actual)
+	want := []Difference{
+		{Kind: Missing, Object: ObjectTable, Name: "audit"},
+		{Kind: Extra, Object: ObjectTable, Name: "hotfix_backup"},
+		{Kind: Missing, Object: ObjectColumn, Table: "orders", Name: "note", Expected: "text"},
+		{Kind: Changed, Object: ObjectColumn, Table: "orders", Name: "total", Expected: "numeric(10,2)", Actual: "numeric(12,2)"},
+		{Kind: Extra, Object: ObjectIndex, Table: "orders", Name: "idx_orders_tmp", Actual: "CREATE INDEX idx_orders_tmp ON orders (id, total);"},
+		{Kind: Missing, Object: ObjectView, Name: "big_orders", Expected: "CREATE VIEW big_orders AS\nSELECT * FROM orders WHERE total > 100;\n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	db := openSQLite(t, testDDL)
+	a, err := Inspect(db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Inspect(db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := Diff(a, b); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %+v", diffs)
+	}
+}
+
+func TestDiff_UnnamedConstraints(t *testing.T) {
+	expected := &Schema{Tables: []Table{{Name: "t", Constraints: []Constraint{{Type: Check, Definition: "CHECK (x > 0)"}}}}}
+	actual := &Schema{Tables: []Table{{Name: "t", Constraints: []Constraint{{Type: Check, Definition: "CHECK (x >= 0)"}}}}}
+
+	diffs := Diff(expected, actual)
+	if len(diffs) != 2 || diffs[0].Kind != Missing || diffs[1].Kind != Extra {
+		t.Errorf("unnamed constraints should differ as missing + extra, got %+v", diffs)
+	}
+}
+
+func TestDifference_QualifiedName(t *testing.T) {
+	if got := (Difference{Table: "orders", Name: "total"}).QualifiedName(); got != "orders.total" {
+		t.Errorf("QualifiedName() = %q", got)
+	}
+	if got := (Difference{Name: "orders"}).QualifiedName(); got != "orders" {
+		t.Errorf("QualifiedName() = %q", got)
+	}
+}
@@ -0,0 +1,287 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Phases order generated statements so that objects are dropped before
+// what they depend on and created after it
+const (
+	phaseDropView = iota
+	phaseDropConstraint
+	phaseDropIndex
+	phaseCreateTable
+	phaseAlterColumn
+	phaseDropColumn
+	phaseDropTable
+	phaseAddConstraint
+	phaseCreateIndex
+	phaseCreateView
+)
+
+// step is one change with the statements that apply and revert it
+type step struct {
+	phase int
+	up    []string
+	down  []string
+}
+
+var referencesPattern = regexp.MustCompile(`(?i)REFERENCES\s+"?([\w.]+)"?`)
+
+// Migrate generates the statements that turn current into desired (up) and
+// back (down). Down is best effort: it recreates dropped objects from their
+// introspected definitions, which loses data. Changes the dialect cannot
+// express, such as altering a SQLite column, are emitted as TODO comments.
+func Migrate(desired, current *Schema, dialect string) (up, down []string) {
+	var steps []step
+	var created []Table
+
+	for _, d := range Diff(desired, current) {
+		switch d.Object {
+		case ObjectTable:
+			if d.Kind == Missing {
+				created = append(created, *desired.Table(d.Name))
+				continue
+			}
+			t := current.Table(d.Name)
+			steps = append(steps, step{phaseDropTable, []string{"DROP TABLE " + t.Name + ";"}, []string{strings.TrimSuffix(t.SQL(), "\n")}})
+		case ObjectColumn:
+			steps = append(steps, columnSteps(d, desired, current, dialect)...)
+		case ObjectConstraint:
+			steps = append(steps, constraintSteps(d, desired, current, dialect)...)
+		case ObjectIndex:
+			steps = append(steps, indexSteps(d, desired, current, dialect)...)
+		case ObjectView:
+			steps = append(steps, viewSteps(d, desired, current)...)
+		}
+	}
+
+	for _, t := range tablesByDependency(created) {
+		steps = append(steps, step{phaseCreateTable, []string{strings.TrimSuffix(t.SQL(), "\n")}, []string{"DROP TABLE IF EXISTS " + t.Name + ";"}})
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].phase < steps[j].phase })
+	for _, s := range steps {
+		up = append(up, s.up...)
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		down = append(down, steps[i].down...)
+	}
+	return up, down
+}
+
+func columnSteps(d Difference, desired, current *Schema, dialect string) []step {
+	switch d.Kind {
+	case Missing:
+		c := findColumn(desired.Table(d.Table), d.Name)
+		return []step{{phaseAlterColumn,
+			[]string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", d.Table, c.SQL())},
+			[]string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", d.Table, c.Name)}}}
+	case Extra:
+		c := findColumn(current.Table(d.Table), d.Name)
+		return []step{{phaseDropColumn,
+			[]string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", d.Table, c.Name)},
+			[]string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", d.Table, c.SQL())}}}
+	}
+	want := findColumn(desired.Table(d.Table), d.Name)
+	have := findColumn(current.Table(d.Table), d.Name)
+	return []step{{phaseAlterColumn, alterColumn(d.Table, have, want, dialect), alterColumn(d.Table, want, have, dialect)}}
+}
+
+// alterColumn changes column from to match to
+func alterColumn(table string, from, to Column, dialect string) []string {
+	switch dialect {
+	case DialectPostgres:
+		prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", table, to.Name)
+		var stmts []string
+		if from.Type != to.Type {
+			stmts = append(stmts, fmt.Sprintf("%sTYPE %s USING %s::%s;", prefix, to.Type, to.Name, to.Type))
+		}
+		if from.Nullable != to.Nullable {
+			if to.Nullable {
+				stmts = append(stmts, prefix+"DROP NOT NULL;")
+			} else {
+				stmts = append(stmts, prefix+"SET NOT NULL;")
+			}
+		}
+		if from.Default != to.Default {
+			if to.Default == "" {
+				stmts = append(stmts, prefix+"DROP DEFAULT;")
+			} else {
+				stmts = append(stmts, prefix+"SET DEFAULT "+to.Default+";")
+			}
+		}
+		return stmts
+	case DialectMySQL:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, to.SQL())}
+	}
+	return []string{fmt.Sprintf("-- TODO: change %s.%s from %q to %q; SQLite cannot alter columns, rebuild the table",
+		table, to.Name, columnDef(from), columnDef(to))}
+}
+
+func constraintSteps(d Difference, desired, current *Schema, dialect string) []step {
+	var steps []step
+	if d.Kind != Missing {
+		c := findConstraint(current.Table(d.Table), d.Name)
+		steps = append(steps, step{phaseDropConstraint, dropConstraint(d.Table, c, dialect), addConstraint(d.Table, c, dialect)})
+	}
+	if d.Kind != Extra {
+		c := findConstraint(desired.Table(d.Table), d.Name)
+		steps = append(steps, step{phaseAddConstraint, addConstraint(d.Table, c, dialect), dropConstraint(d.Table, c, dialect)})
+	}
+	return steps
+}
+
+func addConstraint(table string, c Constraint, dialect string) []string {
+	if dialect == DialectSQLite {
+		return []string{fmt.Sprintf("-- TODO: add %s to %s; SQLite cannot add constraints, rebuild the table", c.Definition, table)}
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD %s;", table, c.SQL())}
+}
+
+func dropConstraint(table string, c Constraint, dialect string) []string {
+	switch {
+	case dialect == DialectSQLite || c.Name == "":
+		return []string{fmt.Sprintf("-- TODO: drop %s from %s; SQLite cannot drop constraints, rebuild the table", c.Definition, table)}
+	case dialect == DialectMySQL:
+		switch c.Type {
+		case PrimaryKey:
+			return []string{fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", table)}
+		case ForeignKey:
+			return []string{fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", table, c.Name)}
+		case Unique:
+			return []string{fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;", table, c.Name)}
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP CHECK %s;", table, c.Name)}
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", table, c.Name)}
+}
+
+func indexSteps(d Difference, desired, current *Schema, dialect string) []step {
+	var steps []step
+	if d.Kind != Missing {
+		idx := findIndex(current.Table(d.Table), d.Name)
+		steps = append(steps, step{phaseDropIndex, []string{dropIndex(d.Table, idx.Name, dialect)}, []string{idx.SQL(d.Table)}})
+	}
+	if d.Kind != Extra {
+		idx := findIndex(desired.Table(d.Table), d.Name)
+		steps = append(steps, step{phaseCreateIndex, []string{idx.SQL(d.Table)}, []string{dropIndex(d.Table, idx.Name, dialect)}})
+	}
+	return steps
+}
+
+func dropIndex(table, name, dialect string) string {
+	if dialect == DialectMySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", name, table)
+	}
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;", name)
+}
+
+func viewSteps(d Difference, desired, current *Schema) []step {
+	var steps []step
+	if d.Kind != Missing {
+		v := findView(current, d.Name)
+		steps = append(steps, step{phaseDropView, []string{dropView(v)}, []string{strings.TrimSuffix(v.SQL(), "\n")}})
+	}
+	if d.Kind != Extra {
+		v := findView(desired, d.Name)
+		steps = append(steps, step{phaseCreateView, []string{strings.TrimSuffix(v.SQL(), "\n")}, []string{dropView(v)}})
+	}
+	return steps
+}
+
+func dropView(v View) string {
+	if v.Materialized {
+		return "DROP MATERIALIZED VIEW IF EXISTS " + v.Name + ";"
+	}
+	return "DROP VIEW IF EXISTS " + v.Name + ";"
+}
+
+// tablesByDependency orders new tables so that tables referenced by a
+// foreign key are created first. Cycles keep name order.
+func tablesByDependency(tables []Table) []Table {
+	pending := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		pending[t.Name] = true
+	}
+
+	var ordered []Table
+	for len(ordered) < len(tables) {
+		progressed := false
+		for _, t := range tables {
+			if !pending[t.Name] || dependsOnPending(t, pending) {
+				continue
+			}
+			ordered = append(ordered, t)
+			pending[t.Name] = false
+			progressed = true
+		}
+		if !progressed {
+			for _, t := range tables {
+				if pending[t.Name] {
+					ordered = append(ordered, t)
+					pending[t.Name] = false
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+func dependsOnPending(t Table, pending map[string]bool) bool {
+	for _, c := range t.Constraints {
+		if c.Type != ForeignKey {
+			continue
+		}
+		if m := referencesPattern.FindStringSubmatch(c.Definition); m != nil && m[1] != t.Name && pending[m[1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// columnDef renders a column definition without its name
+func columnDef(c Column) string {
+	return strings.TrimPrefix(c.SQL(), c.Name+" ")
+}
+
+func findColumn(t *Table, name string) Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return Column{Name: name}
+}
+
+// findConstraint looks a constraint up by name, or by definition for
+// unnamed constraints (see constraintDefs)
+func findConstraint(t *Table, key string) Constraint {
+	for _, c := range t.Constraints {
+		if c.Name == key || (c.Name == "" && c.Definition == key) {
+			return c
+		}
+	}
+	return Constraint{Name: key}
+}
+
+func findIndex(t *Table, name string) Index {
+	for _, idx := range t.Indexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	return Index{Name: name}
+}
+
+func findView(s *Schema, name string) View {
+	for _, v := range s.Views {
+		if v.Name == name {
+			return v
+		}
+	}
+	return View{Name: name}
+}
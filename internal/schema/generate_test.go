@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrate_Postgres(t *testing.T) {
+	current := &Schema{
+		Tables: []Table{{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", Type: "integer"},
+				{Name: "email", Type: "text", Nullable: true},
+				{Name: "legacy", Type: "text", Nullable: true},
+			},
+			Constraints: []Constraint{{Name: "users_pkey", Type: PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+		}},
+	}
+	desired := &Schema{
+		Tables: []Table{
+			{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "email", Type: "character varying(255)"},
+				},
+				Indexes:     []Index{{Name: "users_email_idx", Unique: true, Columns: []string{"email"}}},
+				Constraints: []Constraint{{Name: "users_pkey", Type: PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+			},
+			{
+				Name:    "orders",
+				Columns: []Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}},
+				Constraints: []Constraint{
+					{Name: "orders_user_id_fkey", Type: ForeignKey, Definition: "FOREIGN KEY (user_id) REFERENCES users(id)"},
+				},
+			},
+		},
+	}
+
+	up, down := Migrate(desired, current, DialectPostgres)
+
+	wantUp := []string{
+		"CREATE TABLE orders (\n  id integer NOT NULL,\n  user_id integer NOT NULL,\n  CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id)\n);",
+		"ALTER TABLE users ALTER COLUMN email TYPE character varying(255) USING email::character varying(255);",
+		"ALTER TABLE users ALTER COLUMN email SET NOT NULL;",
+		"ALTER TABLE users DROP COLUMN legacy;",
+		"CREATE UNIQUE INDEX users_email_idx ON users (email);",
+	}
+	wantDown := []string{
+		"DROP INDEX IF EXISTS users_email_idx;",
+		"ALTER TABLE users ADD COLUMN legacy text;",
+		"ALTER TABLE users ALTER COLUMN email TYPE text USING email::text;",
+		"ALTER TABLE users ALTER COLUMN email DROP NOT NULL;",
+		"DROP TABLE IF EXISTS orders;",
+	}
+	if !reflect.DeepEqual(up, wantUp) {
+		t.Errorf("up =\n%q\nwant\n%q", up, wantUp)
+	}
+	if !reflect.DeepEqual(down, wantDown) {
+		t.Errorf("down =\n%q\nwant\n%q", down, wantDown)
+	}
+}
+
+func TestMigrate_MySQL(t *testing.T) {
+	current := &Schema{Tables: []Table{{
+		Name:        "orders",
+		Columns:     []Column{{Name: "total", Type: "decimal(10,2)"}},
+		Constraints: []Constraint{{Name: "orders_ibfk_1", Type: ForeignKey, Definition: "FOREIGN KEY (user_id) REFERENCES users (id)"}},
+		Indexes:     []Index{{Name: "idx_total", Columns: []string{"total"}}},
+	}}}
+	desired := &Schema{Tables: []Table{{
+		Name:    "orders",
+		Columns: []Column{{Name: "total", Type: "decimal(12,2)"}},
+	}}}
+
+	up, down := Migrate(desired, current, DialectMySQL)
+
+	wantUp := []string{
+		"ALTER TABLE orders DROP FOREIGN KEY orders_ibfk_1;",
+		"DROP INDEX idx_total ON orders;",
+		"ALTER TABLE orders MODIFY COLUMN total decimal(12,2) NOT NULL;",
+	}
+	wantDown := []string{
+		"ALTER TABLE orders MODIFY COLUMN total decimal(10,2) NOT NULL;",
+		"CREATE INDEX idx_total ON orders (total);",
+		"ALTER TABLE orders ADD CONSTRAINT orders_ibfk_1 FOREIGN KEY (user_id) REFERENCES users (id);",
+	}
+	if !reflect.DeepEqual(up, wantUp) {
+		t.Errorf("up =\n%q\nwant\n%q", up, wantUp)
+	}
+	if !reflect.DeepEqual(down, wantDown) {
+		t.Errorf("down =\n%q\nwant\n%q", down, wantDown)
+	}
+}
+
+func TestMigrate_SQLiteAlterColumnIsTODO(t *testing.T) {
+	current := &Schema{Tables: []Table{{Name: "t", Columns: []Column{{Name: "x", Type: "INT", Nullable: true}}}}}
+	desired := &Schema{Tables: []Table{{Name: "t", Columns: []Column{{Name: "x", Type: "TEXT", Nullable: true}}}}}
+
+	up, _ := Migrate(desired, current, DialectSQLite)
+	want := []string{`-- TODO: change t.x from "INT" to "TEXT"; SQLite cannot alter columns, rebuild the table`}
+	if !reflect.DeepEqual(up, want) {
+		t.Errorf("up = %q, want %q", up, want)
+	}
+}
+
+func TestMigrate_NoChanges(t *testing.T) {
+	s := &Schema{Tables: []Table{{Name: "t", Columns: []Column{{Name: "x", Type: "INT"}}}}}
+	up, down := Migrate(s, s, DialectPostgres)
+	if len(up) != 0 || len(down) != 0 {
+		t.Errorf("expected no statements, got up=%q down=%q", up, down)
+	}
+}
+
+func TestTablesByDependency(t *testing.T) {
+	tables := []Table{
+		{Name: "a_items", Constraints: []Constraint{{Type: ForeignKey, Definition: "FOREIGN KEY (order_id) REFERENCES orders (id)"}}},
+		{Name: "orders", Constraints: []Constraint{{Type: ForeignKey, Definition: "FOREIGN KEY (user_id) REFERENCES users(id)"}}},
+		{Name: "users", Constraints: []Constraint{{Type: ForeignKey, Definition: "FOREIGN KEY (parent_id) REFERENCES users (id)"}}},
+	}
+
+	var names []string
+	for _, tbl := range tablesByDependency(tables) {
+		names = append(names, tbl.Name)
+	}
+	if want := []string{"users", "orders", "a_items"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tablesByDependency() = %v, want %v", names, want)
+	}
+}
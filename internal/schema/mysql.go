@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// information_schema queries for the current database. CHECK constraints
+// are not read, as information_schema.CHECK_CONSTRAINTS needs MySQL 8.0.16.
+const (
+	myColumnsQuery = `SELECT c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE = 'YES', c.COLUMN_DEFAULT
+FROM information_schema.COLUMNS c
+JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`
+
+	myIndexesQuery = `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE = 0, COALESCE(COLUMN_NAME, '<expression>')
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE()
+ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`
+
+	myForeignKeysQuery = `SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+       r.UPDATE_RULE, r.DELETE_RULE
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+  ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+WHERE k.TABLE_SCHEMA = DATABASE() AND k.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`
+
+	myViewsQuery = `SELECT TABLE_NAME, VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = DATABASE()`
+)
+
+func inspectMySQL(db *sql.DB) (*Schema, error) {
+	s := &Schema{}
+
+	rows, err := db.Query(myColumnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query columns: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var table string
+		var c Column
+		var dflt sql.NullString
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &dflt); err != nil {
+			return err
+		}
+		c.Default = dflt.String
+		if len(s.Tables) == 0 || s.Tables[len(s.Tables)-1].Name != table {
+			s.Tables = append(s.Tables, Table{Name: table})
+		}
+		t := &s.Tables[len(s.Tables)-1]
+		t.Columns = append(t.Columns, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query columns: %w", err)
+	}
+	tables := s.tableIndex()
+
+	// PRIMARY becomes a constraint; unique keys are indexes in MySQL
+	type key struct{ table, name string }
+	var order []key
+	indexes := map[key]*Index{}
+	rows, err = db.Query(myIndexesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query indexes: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var k key
+		var unique bool
+		var column string
+		if err := rows.Scan(&k.table, &k.name, &unique, &column); err != nil {
+			return err
+		}
+		idx, ok := indexes[k]
+		if !ok {
+			idx = &Index{Name: k.name, Unique: unique}
+			indexes[k] = idx
+			order = append(order, k)
+		}
+		idx.Columns = append(idx.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query indexes: %w", err)
+	}
+	for _, k := range order {
+		i, ok := tables[k.table]
+		if !ok {
+			continue
+		}
+		idx := indexes[k]
+		if k.name == "PRIMARY" {
+			s.Tables[i].Constraints = append(s.Tables[i].Constraints, Constraint{
+				Type:       PrimaryKey,
+				Definition: "PRIMARY KEY (" + strings.Join(idx.Columns, ", ") + ")",
+			})
+			continue
+		}
+		s.Tables[i].Indexes = append(s.Tables[i].Indexes, *idx)
+	}
+
+	type fk struct {
+		table, name, refTable, onUpdate, onDelete string
+		from, to                                  []string
+	}
+	var fks []*fk
+	rows, err = db.Query(myForeignKeysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query foreign keys: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var f fk
+		var from, to string
+		if err := rows.Scan(&f.table, &f.name, &from, &f.refTable, &to, &f.onUpdate, &f.onDelete); err != nil {
+			return err
+		}
+		if n := len(fks); n > 0 && fks[n-1].table == f.table && fks[n-1].name == f.name {
+			fks[n-1].from = append(fks[n-1].from, from)
+			fks[n-1].to = append(fks[n-1].to, to)
+			return nil
+		}
+		f.from, f.to = []string{from}, []string{to}
+		fks = append(fks, &f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query foreign keys: %w", err)
+	}
+	for _, f := range fks {
+		i, ok := tables[f.table]
+		if !ok {
+			continue
+		}
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(f.from, ", "), f.refTable, strings.Join(f.to, ", "))
+		def += referentialActions(f.onUpdate, f.onDelete)
+		s.Tables[i].Constraints = append(s.Tables[i].Constraints, Constraint{Name: f.name, Type: ForeignKey, Definition: def})
+	}
+
+	rows, err = db.Query(myViewsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var v View
+		if err := rows.Scan(&v.Name, &v.Definition); err != nil {
+			return err
+		}
+		s.Views = append(s.Views, v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,174 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Catalog queries for the current schema. Relations are matched by name
+// within it, so names do not carry a schema prefix.
+const (
+	pgColumnsQuery = `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+       COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY c.relname, a.attnum`
+
+	pgConstraintsQuery = `SELECT t.relname, con.conname, con.contype, pg_get_constraintdef(con.oid, true)
+FROM pg_constraint con
+JOIN pg_class t ON t.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE n.nspname = current_schema() AND con.contype IN ('p', 'u', 'f', 'c', 'x')`
+
+	// Indexes backing a constraint are reported with the constraint
+	pgIndexesQuery = `SELECT t.relname, i.relname, ix.indisunique,
+       ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k + 1, true) FROM generate_subscripts(ix.indkey, 1) k ORDER BY k),
+       COALESCE(pg_get_expr(ix.indpred, ix.indrelid, true), '')
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE n.nspname = current_schema()
+  AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = ix.indexrelid AND con.contype IN ('p', 'u', 'x'))`
+
+	pgViewsQuery = `SELECT c.relname, c.relkind = 'm', pg_get_viewdef(c.oid, true)
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relkind IN ('v', 'm')`
+)
+
+var pgConstraintTypes = map[string]string{
+	"p": PrimaryKey,
+	"u": Unique,
+	"f": ForeignKey,
+	"c": Check,
+	"x": Exclude,
+}
+
+func inspectPostgres(db *sql.DB) (*Schema, error) {
+	s := &Schema{}
+
+	rows, err := db.Query(pgColumnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query columns: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var table string
+		var c Column
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			return err
+		}
+		if len(s.Tables) == 0 || s.Tables[len(s.Tables)-1].Name != table {
+			s.Tables = append(s.Tables, Table{Name: table})
+		}
+		t := &s.Tables[len(s.Tables)-1]
+		t.Columns = append(t.Columns, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query columns: %w", err)
+	}
+	tables := s.tableIndex()
+
+	rows, err = db.Query(pgConstraintsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query constraints: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var table, kind string
+		var c Constraint
+		if err := rows.Scan(&table, &c.Name, &kind, &c.Definition); err != nil {
+			return err
+		}
+		c.Type = pgConstraintTypes[kind]
+		if i, ok := tables[table]; ok {
+			s.Tables[i].Constraints = append(s.Tables[i].Constraints, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query constraints: %w", err)
+	}
+
+	rows, err = db.Query(pgIndexesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query indexes: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var table, columns string
+		var idx Index
+		if err := rows.Scan(&table, &idx.Name, &idx.Unique, &columns, &idx.Where); err != nil {
+			return err
+		}
+		idx.Columns = parsePgArray(columns)
+		if i, ok := tables[table]; ok {
+			s.Tables[i].Indexes = append(s.Tables[i].Indexes, idx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query indexes: %w", err)
+	}
+
+	rows, err = db.Query(pgViewsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var v View
+		if err := rows.Scan(&v.Name, &v.Materialized, &v.Definition); err != nil {
+			return err
+		}
+		s.Views = append(s.Views, v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+
+	return s, nil
+}
+
+// parsePgArray splits a text[] literal such as {id,"lower(email)"}
+func parsePgArray(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if s == "" {
+		return nil
+	}
+
+	var out []string
+	var cur strings.Builder
+	quoted, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			out = append(out, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return append(out, cur.String())
+}
+
+// scanRows calls scan for each row and closes rows
+func scanRows(rows *sql.Rows, scan func() error) error {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,255 @@
+// Package schema introspects database schemas into a normalized,
+// dialect-independent form that can be rendered, fingerprinted and compared.
+package schema
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Supported dialects; the values match the database URL schemes
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite3"
+)
+
+// Schema is the set of objects in a database schema
+type Schema struct {
+	Tables []Table `json:"tables"`
+	Views  []View  `json:"views"`
+}
+
+// Table is a table with its columns, indexes and constraints
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Indexes     []Index      `json:"indexes"`
+	Constraints []Constraint `json:"constraints"`
+}
+
+// Column is a table column, in table order
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+}
+
+// Index is an index that does not back a constraint
+type Index struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique"`
+	Columns []string `json:"columns"`
+	// Where is the predicate of a partial index
+	Where string `json:"where,omitempty"`
+}
+
+// Constraint types
+const (
+	PrimaryKey = "PRIMARY KEY"
+	ForeignKey = "FOREIGN KEY"
+	Unique     = "UNIQUE"
+	Check      = "CHECK"
+	Exclude    = "EXCLUDE"
+)
+
+// Constraint is a table constraint. Name is empty where the database does
+// not name constraints (SQLite).
+type Constraint struct {
+	Name       string `json:"name,omitempty"`
+	Type       string `json:"type"`
+	Definition string `json:"definition"`
+}
+
+// View is a view or materialized view
+type View struct {
+	Name         string `json:"name"`
+	Materialized bool   `json:"materialized,omitempty"`
+	Definition   string `json:"definition"`
+}
+
+// Inspect reads the schema of the current database (Postgres: current
+// schema). Tables named in exclude, such as the migrations table, are skipped.
+func Inspect(db *sql.DB, dialect string, exclude ...string) (*Schema, error) {
+	var s *Schema
+	var err error
+	switch dialect {
+	case DialectPostgres:
+		s, err = inspectPostgres(db)
+	case DialectMySQL:
+		s, err = inspectMySQL(db)
+	case DialectSQLite:
+		s, err = inspectSQLite(db)
+	default:
+		return nil, fmt.Errorf("schema introspection not supported for %q", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.exclude(exclude)
+	s.normalize()
+	return s, nil
+}
+
+// Table returns the named table, or nil
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// SQL renders the schema as normalized DDL, one object per statement, in
+// name order. Equal schemas render identically.
+func (s *Schema) SQL() string {
+	var b strings.Builder
+	for i, t := range s.Tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(t.SQL())
+	}
+	for _, v := range s.Views {
+		b.WriteString("\n")
+		b.WriteString(v.SQL())
+	}
+	return b.String()
+}
+
+// Fingerprint returns a hash of the normalized schema
+func (s *Schema) Fingerprint() string {
+	sum := sha256.Sum256([]byte(s.SQL()))
+	return hex.EncodeToString(sum[:])
+}
+
+// SQL renders the table with its constraints and indexes
+func (t Table) SQL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n", t.Name)
+	lines := make([]string, 0, len(t.Columns)+len(t.Constraints))
+	for _, c := range t.Columns {
+		lines = append(lines, "  "+c.SQL())
+	}
+	for _, c := range t.Constraints {
+		lines = append(lines, "  "+c.SQL())
+	}
+	b.WriteString(strings.Join(lines, ",\n"))
+	b.WriteString("\n);\n")
+	for _, idx := range t.Indexes {
+		b.WriteString(idx.SQL(t.Name))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// SQL renders the column definition
+func (c Column) SQL() string {
+	def := c.Name + " " + c.Type
+	if !c.Nullable {
+		def += " NOT NULL"
+	}
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	return def
+}
+
+// SQL renders the constraint as a table constraint clause
+func (c Constraint) SQL() string {
+	if c.Name == "" {
+		return c.Definition
+	}
+	return "CONSTRAINT " + c.Name + " " + c.Definition
+}
+
+// SQL renders the index as a CREATE INDEX statement on table
+func (idx Index) SQL(table string) string {
+	kind := "INDEX"
+	if idx.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	stmt := fmt.Sprintf("CREATE %s %s ON %s (%s)", kind, idx.Name, table, strings.Join(idx.Columns, ", "))
+	if idx.Where != "" {
+		stmt += " WHERE " + idx.Where
+	}
+	return stmt + ";"
+}
+
+// SQL renders the view as a CREATE VIEW statement
+func (v View) SQL() string {
+	kind := "VIEW"
+	if v.Materialized {
+		kind = "MATERIALIZED VIEW"
+	}
+	return fmt.Sprintf("CREATE %s %s AS\n%s;\n", kind, v.Name, strings.TrimSuffix(strings.TrimSpace(v.Definition), ";"))
+}
+
+// exclude removes the named tables
+func (s *Schema) exclude(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	skip := make(map[string]bool, len(names))
+	for _, n := range names {
+		skip[n] = true
+	}
+	tables := s.Tables[:0]
+	for _, t := range s.Tables {
+		if !skip[t.Name] {
+			tables = append(tables, t)
+		}
+	}
+	s.Tables = tables
+}
+
+// normalize sorts objects by name so rendering is stable.
+// Columns keep their table order, which is part of the schema.
+func (s *Schema) normalize() {
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	sort.Slice(s.Views, func(i, j int) bool { return s.Views[i].Name < s.Views[j].Name })
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		sort.Slice(t.Indexes, func(a, b int) bool { return t.Indexes[a].Name < t.Indexes[b].Name })
+		sort.Slice(t.Constraints, func(a, b int) bool {
+			ca, cb := t.Constraints[a], t.Constraints[b]
+			if ca.Type != cb.Type {
+				return constraintOrder(ca.Type) < constraintOrder(cb.Type)
+			}
+			if ca.Name != cb.Name {
+				return ca.Name < cb.Name
+			}
+			return ca.Definition < cb.Definition
+		})
+	}
+}
+
+func constraintOrder(typ string) int {
+	switch typ {
+	case PrimaryKey:
+		return 0
+	case Unique:
+		return 1
+	case ForeignKey:
+		return 2
+	case Check:
+		return 3
+	}
+	return 4
+}
+
+// tableIndex returns a lookup from table name to its position in s.Tables
+func (s *Schema) tableIndex() map[string]int {
+	idx := make(map[string]int, len(s.Tables))
+	for i, t := range s.Tables {
+		idx[t.Name] = i
+	}
+	return idx
+}
@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSQLite(t *testing.T, ddl string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "schema.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(ddl); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+const testDDL = `
+CREATE TABLE users (id INTEGER PRIMARY KEY, email text NOT NULL UNIQUE, active BOOLEAN DEFAULT 1);
+CREATE TABLE orders (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	total NUMERIC(10,2)
+);
+CREATE INDEX idx_orders_user ON orders (user_id);
+CREATE INDEX idx_orders_big ON orders (total) WHERE total > 100;
+CREATE VIEW active_users AS SELECT id, email FROM users WHERE active = 1;
+CREATE TABLE schema_migrations (version INTEGER);
+`
+
+func TestInspect_SQLite(t *testing.T) {
+	db := openSQLite(t, testDDL)
+
+	s, err := Inspect(db, DialectSQLite, "schema_migrations")
+	if err != nil {
+		t.Fatalf("Inspect() error: %v", err)
+	}
+
+	want := `CREATE TABLE orders (
+  id INTEGER,
+  user_id INTEGER NOT NULL,
+  total NUMERIC(10,2),
+  PRIMARY KEY (id),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_orders_big ON orders (total) WHERE total > 100;
+CREATE INDEX idx_orders_user ON orders (user_id);
+
+CREATE TABLE users (
+  id INTEGER,
+  email TEXT NOT NULL,
+  active BOOLEAN DEFAULT 1,
+  PRIMARY KEY (id),
+  UNIQUE (email)
+);
+
+CREATE VIEW active_users AS
+SELECT id, email FROM users WHERE active = 1;
+`
+	if got := s.SQL(); got != want {
+		t.Errorf("SQL() mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInspect_ExcludesTables(t *testing.T) {
+	db := openSQLite(t, testDDL)
+
+	s, err := Inspect(db, DialectSQLite, "schema_migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Table("schema_migrations") != nil {
+		t.Error("excluded table should not be inspected")
+	}
+	if s.Table("users") == nil {
+		t.Error("users table missing")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := Inspect(openSQLite(t, testDDL), DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Inspect(openSQLite(t, testDDL), DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Inspect(openSQLite(t, testDDL+"ALTER TABLE users ADD COLUMN name TEXT;"), DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("identical schemas should have equal fingerprints")
+	}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("different schemas should have different fingerprints")
+	}
+}
+
+func TestInspect_UnsupportedDialect(t *testing.T) {
+	if _, err := Inspect(nil, "oracle"); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected unsupported dialect error, got: %v", err)
+	}
+}
+
+func TestParsePgArray(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"{}", nil},
+		{"{id}", []string{"id"}},
+		{`{id,"lower(email)"}`, []string{"id", "lower(email)"}},
+		{`{"a,b","say \"hi\""}`, []string{"a,b", `say "hi"`}},
+	}
+
+	for _, tt := range tests {
+		got := parsePgArray(tt.in)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("parsePgArray(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInspect_SQLiteChecks(t *testing.T) {
+	db := openSQLite(t, `
+CREATE TABLE items (
+	id INTEGER PRIMARY KEY,
+	price NUMERIC(10,2) CHECK (price >= 0),
+	check_code TEXT CONSTRAINT "code_len" CHECK(length(check_code) <= 8),
+	note TEXT DEFAULT 'no CHECK (here)', -- nor CHECK (here)
+	CONSTRAINT price_cap CHECK (price <
+		1000),
+	CHECK ((note IS NULL) OR note <> '')
+);`)
+
+	s, err := Inspect(db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range s.Table("items").Constraints {
+		if c.Type == Check {
+			got = append(got, c.SQL())
+		}
+	}
+	want := []string{
+		"CHECK ((note IS NULL) OR note <> '')",
+		"CHECK (price >= 0)",
+		"CONSTRAINT code_len CHECK (length(check_code) <= 8)",
+		"CONSTRAINT price_cap CHECK (price < 1000)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
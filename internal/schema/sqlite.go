@@ -0,0 +1,359 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// asClausePattern finds the start of a view body in its CREATE statement
+var asClausePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP\s+|TEMPORARY\s+)?VIEW\s+.*?\s+AS\s+`)
+
+// whereClausePattern finds the predicate of a partial index
+var whereClausePattern = regexp.MustCompile(`(?is)\sWHERE\s+(.*)$`)
+
+// inspectSQLite reads the schema through sqlite_master and PRAGMAs.
+// SQLite keeps CHECK constraints only in the CREATE TABLE text, so they are
+// parsed from it.
+func inspectSQLite(db *sql.DB) (*Schema, error) {
+	s := &Schema{}
+
+	names, err := queryStrings(db, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	for _, name := range names {
+		t, err := inspectSQLiteTable(db, name)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		s.Tables = append(s.Tables, *t)
+	}
+
+	rows, err := db.Query(`SELECT name, sql FROM sqlite_master WHERE type = 'view' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list views: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var v View
+		var stmt string
+		if err := rows.Scan(&v.Name, &stmt); err != nil {
+			return nil, err
+		}
+		v.Definition = strings.TrimSpace(asClausePattern.ReplaceAllString(stmt, ""))
+		s.Views = append(s.Views, v)
+	}
+	return s, rows.Err()
+}
+
+func inspectSQLiteTable(db *sql.DB, name string) (*Table, error) {
+	t := &Table{Name: name}
+	quoted := quoteSQLite(name)
+
+	// Columns and primary key
+	rows, err := db.Query("PRAGMA table_info(" + quoted + ")")
+	if err != nil {
+		return nil, err
+	}
+	pk := map[int]string{}
+	for rows.Next() {
+		var cid, notNull, pkPos int
+		var c Column
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &c.Name, &c.Type, &notNull, &dflt, &pkPos); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		c.Type = strings.ToUpper(c.Type)
+		c.Nullable = notNull == 0
+		c.Default = dflt.String
+		t.Columns = append(t.Columns, c)
+		if pkPos > 0 {
+			pk[pkPos] = c.Name
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(pk) > 0 {
+		cols := make([]string, len(pk))
+		for pos, col := range pk {
+			cols[pos-1] = col
+		}
+		t.Constraints = append(t.Constraints, Constraint{Type: PrimaryKey, Definition: "PRIMARY KEY (" + strings.Join(cols, ", ") + ")"})
+	}
+
+	// Indexes and UNIQUE constraints
+	type indexEntry struct {
+		name    string
+		unique  bool
+		origin  string
+		partial bool
+	}
+	var entries []indexEntry
+	rows, err = db.Query("PRAGMA index_list(" + quoted + ")")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var seq, unique, partial int
+		var e indexEntry
+		if err := rows.Scan(&seq, &e.name, &unique, &e.origin, &partial); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		e.unique, e.partial = unique == 1, partial == 1
+		entries = append(entries, e)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.origin == "pk" {
+			continue
+		}
+		cols, err := sqliteIndexColumns(db, e.name)
+		if err != nil {
+			return nil, err
+		}
+		if e.origin == "u" {
+			t.Constraints = append(t.Constraints, Constraint{Type: Unique, Definition: "UNIQUE (" + strings.Join(cols, ", ") + ")"})
+			continue
+		}
+		idx := Index{Name: e.name, Unique: e.unique, Columns: cols}
+		if e.partial {
+			var stmt string
+			if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?`, e.name).Scan(&stmt); err != nil {
+				return nil, err
+			}
+			if m := whereClausePattern.FindStringSubmatch(stmt); m != nil {
+				idx.Where = strings.TrimSpace(m[1])
+			}
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+
+	// Foreign keys, grouped by id
+	rows, err = db.Query("PRAGMA foreign_key_list(" + quoted + ")")
+	if err != nil {
+		return nil, err
+	}
+	type fk struct {
+		table, onUpdate, onDelete string
+		from, to                  []string
+	}
+	fks := map[int]*fk{}
+	for rows.Next() {
+		var id, seq int
+		var table, from, onUpdate, onDelete, match string
+		var to sql.NullString
+		if err := rows.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		f, ok := fks[id]
+		if !ok {
+			f = &fk{table: table, onUpdate: onUpdate, onDelete: onDelete}
+			fks[id] = f
+		}
+		f.from = append(f.from, from)
+		if to.Valid {
+			f.to = append(f.to, to.String)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(fks))
+	for id := range fks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		f := fks[id]
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", strings.Join(f.from, ", "), f.table)
+		if len(f.to) > 0 {
+			def += " (" + strings.Join(f.to, ", ") + ")"
+		}
+		def += referentialActions(f.onUpdate, f.onDelete)
+		t.Constraints = append(t.Constraints, Constraint{Type: ForeignKey, Definition: def})
+	}
+
+	// CHECK constraints, which no PRAGMA lists
+	var stmt string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&stmt); err != nil {
+		return nil, err
+	}
+	t.Constraints = append(t.Constraints, sqliteChecks(stmt)...)
+
+	return t, nil
+}
+
+// sqliteChecks parses the column and table CHECK constraints out of a
+// CREATE TABLE statement. Whitespace in the expressions is collapsed.
+func sqliteChecks(stmt string) []Constraint {
+	var (
+		checks []Constraint
+		// words of the column or table constraint definition being read
+		words []string
+		depth int
+	)
+	for i := 0; i < len(stmt); {
+		if next := sqliteSkipQuoted(stmt, i); next > i {
+			if c := stmt[i]; depth == 1 && (c == '"' || c == '`' || c == '[') {
+				words = append(words, stmt[i+1:next-1])
+			}
+			i = next
+			continue
+		}
+		switch c := stmt[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 1:
+			words = words[:0]
+		case isSQLiteWordByte(c):
+			start := i
+			for i < len(stmt) && isSQLiteWordByte(stmt[i]) {
+				i++
+			}
+			if depth != 1 {
+				continue
+			}
+			word := stmt[start:i]
+			open := i
+			for open < len(stmt) && strings.ContainsRune(" \t\r\n", rune(stmt[open])) {
+				open++
+			}
+			if strings.EqualFold(word, "CHECK") && open < len(stmt) && stmt[open] == '(' {
+				end := sqliteParenEnd(stmt, open)
+				con := Constraint{Type: Check, Definition: "CHECK (" + strings.Join(strings.Fields(stmt[open+1:end]), " ") + ")"}
+				if n := len(words); n >= 2 && strings.EqualFold(words[n-2], "CONSTRAINT") {
+					con.Name = words[n-1]
+				}
+				checks = append(checks, con)
+				i = end + 1
+				continue
+			}
+			words = append(words, word)
+			continue
+		}
+		i++
+	}
+	return checks
+}
+
+// sqliteParenEnd returns the index of the parenthesis closing the one at open
+func sqliteParenEnd(stmt string, open int) int {
+	depth := 0
+	for i := open; i < len(stmt); {
+		if next := sqliteSkipQuoted(stmt, i); next > i {
+			i = next
+			continue
+		}
+		switch stmt[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return len(stmt)
+}
+
+// sqliteSkipQuoted returns the index after the string, quoted identifier
+// or comment starting at i, or i if none starts there. A doubled quote is
+// read as two adjacent strings.
+func sqliteSkipQuoted(stmt string, i int) int {
+	opening, closing := 1, ""
+	switch {
+	case stmt[i] == '\'' || stmt[i] == '"' || stmt[i] == '`':
+		closing = stmt[i : i+1]
+	case stmt[i] == '[':
+		closing = "]"
+	case strings.HasPrefix(stmt[i:], "--"):
+		opening, closing = 2, "\n"
+	case strings.HasPrefix(stmt[i:], "/*"):
+		opening, closing = 2, "*/"
+	default:
+		return i
+	}
+	if end := strings.Index(stmt[i+opening:], closing); end >= 0 {
+		return i + opening + end + len(closing)
+	}
+	return len(stmt)
+}
+
+func isSQLiteWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// sqliteIndexColumns lists the columns of an index in key order
+func sqliteIndexColumns(db *sql.DB, index string) ([]string, error) {
+	rows, err := db.Query("PRAGMA index_info(" + quoteSQLite(index) + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var cols []string
+	for rows.Next() {
+		var seqno, cid int
+		var name sql.NullString
+		if err := rows.Scan(&seqno, &cid, &name); err != nil {
+			return nil, err
+		}
+		if name.Valid {
+			cols = append(cols, name.String)
+		} else {
+			cols = append(cols, "<expression>")
+		}
+	}
+	return cols, rows.Err()
+}
+
+// referentialActions renders ON UPDATE / ON DELETE clauses that differ
+// from the default
+func referentialActions(onUpdate, onDelete string) string {
+	var s string
+	if onUpdate != "" && !strings.EqualFold(onUpdate, "NO ACTION") {
+		s += " ON UPDATE " + strings.ToUpper(onUpdate)
+	}
+	if onDelete != "" && !strings.EqualFold(onDelete, "NO ACTION") {
+		s += " ON DELETE " + strings.ToUpper(onDelete)
+	}
+	return s
+}
+
+func quoteSQLite(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// queryStrings returns the first column of every row
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
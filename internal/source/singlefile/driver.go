@@ -3,8 +3,9 @@ package singlefile
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"sort"
 	"strings"
 
@@ -17,7 +18,8 @@ func init() {
 
 // Driver implements source.Driver for single-file up/down migrations
 type Driver struct {
-	path       string
+	fsys       fs.FS
+	dir        string
 	migrations map[uint]Migration
 	versions   []uint // sorted ascending
 }
@@ -36,16 +38,7 @@ func (d *Driver) Open(url string) (source.Driver, error) {
 		return nil, fmt.Errorf("migrations path is not a directory: %s", path)
 	}
 
-	driver := &Driver{
-		path:       path,
-		migrations: make(map[uint]Migration),
-	}
-
-	if err := driver.scanMigrations(); err != nil {
-		return nil, err
-	}
-
-	return driver, nil
+	return newDriver(os.DirFS(path), ".")
 }
 
 // Close releases resources
@@ -105,18 +98,15 @@ func (d *Driver) ReadDown(version uint) (io.ReadCloser, string, error) {
 	return io.NopCloser(strings.NewReader(m.Down)), m.Name, nil
 }
 
-// scanMigrations reads all .sql files from the migrations directory
+// scanMigrations reads all .sql files from the migrations directory.
+// fs.FS only accepts paths inside its root, so a migration cannot be read
+// from outside the migrations directory.
 func (d *Driver) scanMigrations() error {
-	entries, err := os.ReadDir(d.path)
+	entries, err := fs.ReadDir(d.fsys, d.dir)
 	if err != nil {
 		return fmt.Errorf("read migrations dir: %w", err)
 	}
 
-	absPath, err := filepath.Abs(d.path)
-	if err != nil {
-		return fmt.Errorf("resolve migrations path: %w", err)
-	}
-
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
@@ -127,17 +117,7 @@ func (d *Driver) scanMigrations() error {
 			continue
 		}
 
-		// Security: prevent path traversal by validating resolved path stays within migrations dir
-		filePath := filepath.Join(d.path, entry.Name())
-		absFilePath, err := filepath.Abs(filePath)
-		if err != nil {
-			continue
-		}
-		if !strings.HasPrefix(absFilePath, absPath+string(filepath.Separator)) {
-			continue
-		}
-
-		m, err := parseMigrationFile(filePath)
+		m, err := parseMigrationFile(d.fsys, path.Join(d.dir, entry.Name()))
 		if err != nil {
 			return err
 		}
@@ -169,8 +149,26 @@ func NewWithPath(path string) (source.Driver, error) {
 		return nil, fmt.Errorf("migrations path is not a directory: %s", path)
 	}
 
+	return newDriver(os.DirFS(path), ".")
+}
+
+// NewWithFS creates a driver from directory dir of fsys, such as an
+// embed.FS compiled into the application. Use "." for the root of fsys.
+func NewWithFS(fsys fs.FS, dir string) (source.Driver, error) {
+	info, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations path: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("migrations path is not a directory: %s", dir)
+	}
+	return newDriver(fsys, dir)
+}
+
+func newDriver(fsys fs.FS, dir string) (source.Driver, error) {
 	d := &Driver{
-		path:       path,
+		fsys:       fsys,
+		dir:        dir,
 		migrations: make(map[uint]Migration),
 	}
 	if err := d.scanMigrations(); err != nil {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/golang-migrate/migrate/v4/source"
 )
@@ -370,3 +371,30 @@ func TestDriver_GetVersions(t *testing.T) {
 		}
 	}
 }
+
+func TestNewWithFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/migrations/000002_second.sql": {Data: []byte("-- +migrate UP\nCREATE TABLE b (id INT);")},
+		"db/migrations/000001_first.sql":  {Data: []byte("-- +migrate UP\nCREATE TABLE a (id INT);")},
+		"db/migrations/README.md":         {Data: []byte("notes")},
+	}
+
+	d, err := NewWithFS(fsys, "db/migrations")
+	if err != nil {
+		t.Fatalf("NewWithFS() error: %v", err)
+	}
+	versions := d.(*Driver).GetVersions()
+	if len(versions) != 2 || versions[0] != 1 || versions[1] != 2 {
+		t.Errorf("versions = %v; want [1 2]", versions)
+	}
+	if _, name, err := d.ReadUp(1); err != nil || name != "first" {
+		t.Errorf("ReadUp(1) = %q, %v; want first", name, err)
+	}
+
+	if _, err := NewWithFS(fsys, "missing"); err == nil {
+		t.Error("expected error for missing directory")
+	}
+	if _, err := NewWithFS(fsys, "db/migrations/README.md"); err == nil {
+		t.Error("expected error for file path")
+	}
+}
@@ -2,12 +2,15 @@ package singlefile
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -15,6 +18,8 @@ var (
 	filenamePattern = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
 	upMarker        = "-- +migrate UP"
 	downMarker      = "-- +migrate DOWN"
+	// directivePrefix starts any other "-- +migrate Key: value" line
+	directivePrefix = "-- +migrate "
 )
 
 // Migration represents a parsed migration file
@@ -23,11 +28,19 @@ type Migration struct {
 	Name    string
 	Up      string
 	Down    string
+	// Checksum is the hex-encoded SHA-256 of the raw file content
+	Checksum string
+	// StatementTimeout and LockTimeout override the environment's session
+	// timeouts for this migration (0 = use environment setting)
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+	// LintIgnore lists lint rules suppressed for this migration
+	LintIgnore []string
 }
 
-// parseMigrationFile reads and parses a single migration file
-func parseMigrationFile(path string) (Migration, error) {
-	filename := filepath.Base(path)
+// parseMigrationFile reads and parses a single migration file of fsys
+func parseMigrationFile(fsys fs.FS, file string) (Migration, error) {
+	filename := path.Base(file)
 	matches := filenamePattern.FindStringSubmatch(filename)
 	if matches == nil {
 		return Migration{}, fmt.Errorf("invalid migration filename: %s (expected format: {version}_{name}.sql)", filename)
@@ -39,19 +52,35 @@ func parseMigrationFile(path string) (Migration, error) {
 	}
 	name := matches[2]
 
-	content, err := os.ReadFile(path)
+	content, err := fs.ReadFile(fsys, file)
 	if err != nil {
 		return Migration{}, fmt.Errorf("read migration file %s: %w", filename, err)
 	}
 
 	up, down := parseContent(string(content))
+	sum := sha256.Sum256(content)
 
-	return Migration{
-		Version: uint(version),
-		Name:    name,
-		Up:      up,
-		Down:    down,
-	}, nil
+	m := Migration{
+		Version:  uint(version),
+		Name:     name,
+		Up:       up,
+		Down:     down,
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+
+	for _, d := range parseDirectives(string(content)) {
+		switch {
+		case strings.EqualFold(d.Key, "Timeout"):
+			m.StatementTimeout, m.LockTimeout, err = parseTimeoutDirective(d.Value)
+			if err != nil {
+				return Migration{}, fmt.Errorf("%s: %w", filename, err)
+			}
+		case strings.EqualFold(d.Key, "lint-ignore"):
+			m.LintIgnore = append(m.LintIgnore, parseListDirective(d.Value)...)
+		}
+	}
+
+	return m, nil
 }
 
 // parseContent extracts UP and DOWN sections from migration content
@@ -71,6 +100,9 @@ func parseContent(content string) (up, down string) {
 		case strings.HasPrefix(trimmed, downMarker):
 			currentSection = "down"
 			continue
+		case strings.HasPrefix(trimmed, directivePrefix):
+			// Directives configure the migration and are not part of its SQL
+			continue
 		}
 
 		switch currentSection {
@@ -85,6 +117,74 @@ func parseContent(content string) (up, down string) {
 		strings.TrimSpace(strings.Join(downLines, "\n"))
 }
 
+// Directive is a "-- +migrate Key: value" line other than the UP/DOWN markers
+type Directive struct {
+	Key   string
+	Value string
+}
+
+// parseDirectives returns all directives in file order
+func parseDirectives(content string) []Directive {
+	var directives []Directive
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(trimmed, directivePrefix) ||
+			strings.HasPrefix(trimmed, upMarker) || strings.HasPrefix(trimmed, downMarker) {
+			continue
+		}
+
+		body := strings.TrimSpace(strings.TrimPrefix(trimmed, directivePrefix))
+		key, value, _ := strings.Cut(body, ":")
+		directives = append(directives, Directive{
+			Key:   strings.TrimSpace(key),
+			Value: strings.TrimSpace(value),
+		})
+	}
+
+	return directives
+}
+
+// parseTimeoutDirective parses the value of a Timeout directive.
+// Accepted forms: "30s" (statement timeout), "statement=5m lock=10s".
+func parseTimeoutDirective(value string) (statement, lock time.Duration, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("timeout directive requires a value")
+	}
+
+	for _, field := range fields {
+		key, raw, hasKey := strings.Cut(field, "=")
+		if !hasKey {
+			key, raw = "statement", field
+		}
+
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("invalid timeout %q: expected a duration like 30s or 5m", raw)
+		}
+
+		switch strings.ToLower(key) {
+		case "statement":
+			statement = d
+		case "lock":
+			lock = d
+		default:
+			return 0, 0, fmt.Errorf("unknown timeout %q (expected statement or lock)", key)
+		}
+	}
+
+	return statement, lock, nil
+}
+
+// parseListDirective splits a comma or space separated directive value
+func parseListDirective(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
 // validateFilename checks if a filename matches migration pattern
 func validateFilename(filename string) bool {
 	return filenamePattern.MatchString(filename)
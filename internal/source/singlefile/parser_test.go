@@ -3,7 +3,9 @@ package singlefile
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseContent(t *testing.T) {
@@ -85,6 +87,17 @@ DROP TABLE users;`,
 			wantUp:   "-- Create users table\nCREATE TABLE users (id INT);\n-- End of up migration",
 			wantDown: "-- Drop users table\nDROP TABLE users;",
 		},
+		{
+			name: "directives excluded from sections",
+			content: `-- +migrate UP
+-- +migrate Timeout: 30s
+CREATE TABLE users (id INT);
+
+-- +migrate DOWN
+DROP TABLE users;`,
+			wantUp:   "CREATE TABLE users (id INT);",
+			wantDown: "DROP TABLE users;",
+		},
 	}
 
 	for _, tc := range tests {
@@ -145,7 +158,7 @@ DROP TABLE IF EXISTS users;`
 		t.Fatal(err)
 	}
 
-	m, err := parseMigrationFile(path)
+	m, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
 	if err != nil {
 		t.Fatalf("parseMigrationFile() error: %v", err)
 	}
@@ -162,6 +175,34 @@ DROP TABLE IF EXISTS users;`
 	if m.Down != "DROP TABLE IF EXISTS users;" {
 		t.Errorf("Down content mismatch: %q", m.Down)
 	}
+	if len(m.Checksum) != 64 {
+		t.Errorf("Checksum = %q; want 64 hex chars", m.Checksum)
+	}
+}
+
+func TestParseMigrationFile_ChecksumChangesWithContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "000001_test.sql")
+
+	if err := os.WriteFile(path, []byte("-- +migrate UP\nCREATE TABLE t (id INT);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("-- +migrate UP\nCREATE TABLE t (id BIGINT);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Checksum == second.Checksum {
+		t.Error("checksum should change when file content changes")
+	}
 }
 
 func TestParseMigrationFile_InvalidFilename(t *testing.T) {
@@ -171,15 +212,112 @@ func TestParseMigrationFile_InvalidFilename(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := parseMigrationFile(path)
+	_, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
 	if err == nil {
 		t.Error("expected error for invalid filename")
 	}
 }
 
 func TestParseMigrationFile_NotFound(t *testing.T) {
-	_, err := parseMigrationFile("/nonexistent/path/000001_test.sql")
+	_, err := parseMigrationFile(os.DirFS("/nonexistent/path"), "000001_test.sql")
 	if err == nil {
 		t.Error("expected error for nonexistent file")
 	}
 }
+
+func TestParseTimeoutDirective(t *testing.T) {
+	tests := []struct {
+		value     string
+		statement time.Duration
+		lock      time.Duration
+		wantErr   bool
+	}{
+		{"30s", 30 * time.Second, 0, false},
+		{"statement=5m lock=10s", 5 * time.Minute, 10 * time.Second, false},
+		{"lock=2s", 0, 2 * time.Second, false},
+		{"", 0, 0, true},
+		{"forever", 0, 0, true},
+		{"row=1s", 0, 0, true},
+		{"-5s", 0, 0, true},
+	}
+
+	for _, tc := range tests {
+		statement, lock, err := parseTimeoutDirective(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseTimeoutDirective(%q): expected error", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeoutDirective(%q) error: %v", tc.value, err)
+			continue
+		}
+		if statement != tc.statement || lock != tc.lock {
+			t.Errorf("parseTimeoutDirective(%q) = %v, %v; want %v, %v", tc.value, statement, lock, tc.statement, tc.lock)
+		}
+	}
+}
+
+func TestParseMigrationFile_TimeoutDirective(t *testing.T) {
+	dir := t.TempDir()
+	content := `-- +migrate Timeout: statement=1m lock=5s
+-- +migrate UP
+CREATE INDEX idx ON users(email);
+
+-- +migrate DOWN
+DROP INDEX idx;`
+
+	path := filepath.Join(dir, "000002_add_index.sql")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
+	if err != nil {
+		t.Fatalf("parseMigrationFile() error: %v", err)
+	}
+	if m.StatementTimeout != time.Minute || m.LockTimeout != 5*time.Second {
+		t.Errorf("timeouts = %v, %v; want 1m, 5s", m.StatementTimeout, m.LockTimeout)
+	}
+}
+
+func TestParseMigrationFile_InvalidTimeoutDirective(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "000001_bad.sql")
+	if err := os.WriteFile(path, []byte("-- +migrate Timeout: soon\n-- +migrate UP\nSELECT 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path)); err == nil {
+		t.Error("expected error for invalid timeout directive")
+	}
+}
+
+func TestParseMigrationFile_LintIgnoreDirective(t *testing.T) {
+	dir := t.TempDir()
+	content := `-- +migrate lint-ignore:drop_table
+-- +migrate lint-ignore: drop_column, table_rewrite
+-- +migrate UP
+DROP TABLE legacy;
+
+-- +migrate DOWN
+CREATE TABLE legacy (id INT);`
+
+	path := filepath.Join(dir, "000003_drop_legacy.sql")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := parseMigrationFile(os.DirFS(dir), filepath.Base(path))
+	if err != nil {
+		t.Fatalf("parseMigrationFile() error: %v", err)
+	}
+	want := []string{"drop_table", "drop_column", "table_rewrite"}
+	if strings.Join(m.LintIgnore, ",") != strings.Join(want, ",") {
+		t.Errorf("LintIgnore = %v, want %v", m.LintIgnore, want)
+	}
+	if m.Up != "DROP TABLE legacy;" {
+		t.Errorf("directive leaked into UP: %q", m.Up)
+	}
+}
@@ -2,9 +2,22 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
+// Output receives human-readable messages: those of Success, Warning and
+// Info, and what commands print alongside them. A command streaming
+// machine-readable data to stdout points it at stderr.
+var Output io.Writer = stdout{}
+
+// stdout writes to whatever os.Stdout is at the time of the write
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
 // ANSI color codes
 const (
 	ColorReset  = "\033[0m"
@@ -21,21 +34,29 @@ func UseColor() bool {
 	return IsTTY() && os.Getenv("NO_COLOR") == ""
 }
 
+// Colorize wraps s in color when colored output is enabled
+func Colorize(color, s string) string {
+	if !UseColor() {
+		return s
+	}
+	return color + s + ColorReset
+}
+
 // Success prints a success message with green checkmark
 func Success(msg string) {
 	if UseColor() {
-		fmt.Printf("%s%s%s %s%s\n", ColorGreen, ColorBold, "OK", msg, ColorReset)
+		fmt.Fprintf(Output, "%s%s%s %s%s\n", ColorGreen, ColorBold, "OK", msg, ColorReset)
 	} else {
-		fmt.Printf("OK %s\n", msg)
+		fmt.Fprintf(Output, "OK %s\n", msg)
 	}
 }
 
 // Warning prints a warning message in yellow
 func Warning(msg string) {
 	if UseColor() {
-		fmt.Printf("%s! %s%s\n", ColorYellow, msg, ColorReset)
+		fmt.Fprintf(Output, "%s! %s%s\n", ColorYellow, msg, ColorReset)
 	} else {
-		fmt.Printf("! %s\n", msg)
+		fmt.Fprintf(Output, "! %s\n", msg)
 	}
 }
 
@@ -51,8 +72,8 @@ func Error(msg string) {
 // Info prints an informational message in blue
 func Info(msg string) {
 	if UseColor() {
-		fmt.Printf("%s* %s%s\n", ColorBlue, msg, ColorReset)
+		fmt.Fprintf(Output, "%s* %s%s\n", ColorBlue, msg, ColorReset)
 	} else {
-		fmt.Printf("* %s\n", msg)
+		fmt.Fprintf(Output, "* %s\n", msg)
 	}
 }
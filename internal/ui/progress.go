@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProgressList renders a list of migration steps as they run.
+// In live mode (TTY) the running step is shown and then replaced in place
+// with its result; otherwise each start and result is printed on its own line.
+type ProgressList struct {
+	w     io.Writer
+	live  bool
+	color bool
+
+	mu      sync.Mutex
+	pending bool // a live "running" line is on screen
+}
+
+// NewProgressList creates a progress list writing to w
+func NewProgressList(w io.Writer, live bool) *ProgressList {
+	return &ProgressList{w: w, live: live, color: live && UseColor()}
+}
+
+// Start shows a step as running
+func (p *ProgressList) Start(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.live {
+		_, _ = fmt.Fprintf(p.w, "  ... %s", label)
+		p.pending = true
+		return
+	}
+	_, _ = fmt.Fprintf(p.w, "  ... %s\n", label)
+}
+
+// Finish marks a step as completed
+func (p *ProgressList) Finish(label string, d time.Duration) {
+	p.result(ColorGreen, "OK  ", label, d, "")
+}
+
+// Fail marks a step as failed
+func (p *ProgressList) Fail(label string, d time.Duration, err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	p.result(ColorRed, "FAIL", label, d, msg)
+}
+
+func (p *ProgressList) result(color, status, label string, d time.Duration, msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending {
+		// Return to line start and clear the "running" line
+		_, _ = fmt.Fprint(p.w, "\r\033[K")
+		p.pending = false
+	}
+
+	if p.color {
+		status = color + status + ColorReset
+	}
+	_, _ = fmt.Fprintf(p.w, "  %s %s (%s)\n", status, label, FormatDuration(d))
+	if msg != "" {
+		_, _ = fmt.Fprintf(p.w, "       %s\n", msg)
+	}
+}
+
+// FormatDuration renders a duration rounded for display
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(10 * time.Millisecond).String()
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressList_Plain(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgressList(&buf, false)
+
+	p.Start("000001 create_users (up)")
+	p.Finish("000001 create_users (up)", 12*time.Millisecond)
+
+	want := "  ... 000001 create_users (up)\n  OK   000001 create_users (up) (12ms)\n"
+	if buf.String() != want {
+		t.Errorf("output mismatch:\ngot:  %q\nwant: %q", buf.String(), want)
+	}
+}
+
+func TestProgressList_LiveReplacesRunningLine(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgressList(&buf, true)
+	p.color = false
+
+	p.Start("000002 add_index (up)")
+	p.Fail("000002 add_index (up)", 2*time.Second, errors.New("lock timeout"))
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 2 {
+		t.Errorf("live mode should not end the running line: %q", out)
+	}
+	if !strings.Contains(out, "\r\033[K  FAIL 000002 add_index (up) (2s)") {
+		t.Errorf("running line should be replaced by result: %q", out)
+	}
+	if !strings.Contains(out, "lock timeout") {
+		t.Errorf("failure message missing: %q", out)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Nanosecond, "2µs"},
+		{1234567 * time.Nanosecond, "1ms"},
+		{1234 * time.Millisecond, "1.23s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
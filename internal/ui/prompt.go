@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -14,6 +15,17 @@ func IsTTY() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
 
+// promptOutput is where prompts are drawn: Output, so that they stay off a
+// stdout carrying machine-readable data
+func promptOutput() io.WriteCloser {
+	return nopCloser{Output}
+}
+
+// nopCloser keeps promptui from closing Output
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
 // Confirm prompts user for yes/no confirmation.
 // Returns true if confirmed, false otherwise.
 // defaultNo=true means default is "n" (safer for destructive ops).
@@ -22,16 +34,11 @@ func Confirm(message string, defaultNo bool) (bool, error) {
 		return false, fmt.Errorf("not a TTY: use --auto-approve for non-interactive mode")
 	}
 
-	label := message + " [y/N]"
-	if !defaultNo {
-		label = message + " [Y/n]"
-	}
-
-	prompt := promptui.Prompt{
-		Label:     label,
-		IsConfirm: true,
-	}
+	return runConfirm(confirmPrompt(message, defaultNo), defaultNo)
+}
 
+// runConfirm runs a prompt built by confirmPrompt and reads its answer
+func runConfirm(prompt promptui.Prompt, defaultNo bool) (bool, error) {
 	result, err := prompt.Run()
 	if err != nil {
 		if err == promptui.ErrAbort {
@@ -47,6 +54,21 @@ func Confirm(message string, defaultNo bool) (bool, error) {
 	return answer == "y" || answer == "yes", nil
 }
 
+// confirmPrompt builds the yes/no prompt run by Confirm. promptui shows
+// [y/N] or [Y/n] itself, and reports an empty answer as ErrAbort unless
+// Default is "y".
+func confirmPrompt(message string, defaultNo bool) promptui.Prompt {
+	prompt := promptui.Prompt{
+		Label:     message,
+		IsConfirm: true,
+		Stdout:    promptOutput(),
+	}
+	if !defaultNo {
+		prompt.Default = "y"
+	}
+	return prompt
+}
+
 // ConfirmProduction prompts for extra confirmation for production.
 // Requires typing environment name to confirm.
 func ConfirmProduction(envName string) (bool, error) {
@@ -54,14 +76,15 @@ func ConfirmProduction(envName string) (bool, error) {
 		return false, fmt.Errorf("not a TTY: use --auto-approve for non-interactive mode")
 	}
 
-	fmt.Println()
+	fmt.Fprintln(Output)
 	Warning(fmt.Sprintf("You are about to modify PRODUCTION environment: %s", envName))
-	fmt.Println()
+	fmt.Fprintln(Output)
 
 	// First confirmation
 	prompt1 := promptui.Prompt{
 		Label:     "Continue? [y/N]",
 		IsConfirm: true,
+		Stdout:    promptOutput(),
 	}
 
 	if _, err := prompt1.Run(); err != nil {
@@ -70,7 +93,8 @@ func ConfirmProduction(envName string) (bool, error) {
 
 	// Second confirmation - type environment name
 	prompt2 := promptui.Prompt{
-		Label: fmt.Sprintf("Type '%s' to confirm", envName),
+		Label:  fmt.Sprintf("Type '%s' to confirm", envName),
+		Stdout: promptOutput(),
 		Validate: func(input string) error {
 			if input != envName {
 				return fmt.Errorf("input does not match '%s'", envName)
@@ -94,14 +118,15 @@ func ConfirmDangerous(operation, details string) (bool, error) {
 		return false, fmt.Errorf("not a TTY: use --auto-approve for non-interactive mode")
 	}
 
-	fmt.Println()
-	fmt.Println("WARNING: DANGEROUS OPERATION")
-	fmt.Println(details)
-	fmt.Println()
+	fmt.Fprintln(Output)
+	fmt.Fprintln(Output, "WARNING: DANGEROUS OPERATION")
+	fmt.Fprintln(Output, details)
+	fmt.Fprintln(Output)
 
 	prompt := promptui.Prompt{
 		Label:     fmt.Sprintf("Proceed with %s? [y/N]", operation),
 		IsConfirm: true,
+		Stdout:    promptOutput(),
 	}
 
 	_, err := prompt.Run()
@@ -114,3 +139,48 @@ func ConfirmDangerous(operation, details string) (bool, error) {
 
 	return true, nil
 }
+
+// Select prompts the user to pick one of items.
+// Returns the index of the chosen item.
+func Select(label string, items []string) (int, error) {
+	if !IsTTY() {
+		return -1, fmt.Errorf("not a TTY: cannot prompt for a choice")
+	}
+
+	prompt := promptui.Select{
+		Label:  label,
+		Items:  items,
+		Size:   len(items),
+		Stdout: promptOutput(),
+	}
+
+	index, _, err := prompt.Run()
+	if err != nil {
+		return -1, err
+	}
+	return index, nil
+}
+
+// Input prompts the user for a line of text.
+// Returns def when the answer is empty.
+func Input(label, def string) (string, error) {
+	if !IsTTY() {
+		return "", fmt.Errorf("not a TTY: cannot prompt for input")
+	}
+
+	prompt := promptui.Prompt{
+		Label:     label,
+		Default:   def,
+		AllowEdit: true,
+		Stdout:    promptOutput(),
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	if answer := strings.TrimSpace(result); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
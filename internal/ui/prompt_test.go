@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"bytes"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +54,66 @@ func TestConfirmDangerous_NonTTY(t *testing.T) {
 		t.Error("ConfirmDangerous should return false in non-TTY environment")
 	}
 }
+
+func TestSelect_NonTTY(t *testing.T) {
+	if IsTTY() {
+		t.Skip("Skipping non-TTY test in TTY environment")
+	}
+
+	index, err := Select("Pick", []string{"a", "b"})
+	if err == nil {
+		t.Error("expected error in non-TTY mode")
+	}
+	if index != -1 {
+		t.Errorf("expected -1, got %d", index)
+	}
+}
+
+func TestInput_NonTTY(t *testing.T) {
+	if IsTTY() {
+		t.Skip("Skipping non-TTY test in TTY environment")
+	}
+
+	if _, err := Input("Name", "app"); err == nil {
+		t.Error("expected error in non-TTY mode")
+	}
+}
+
+func TestRunConfirm_Answers(t *testing.T) {
+	tests := []struct {
+		input     string
+		defaultNo bool
+		want      bool
+	}{
+		{"\n", false, true},
+		{"\n", true, false},
+		{"y\n", true, true},
+		{"n\n", false, false},
+	}
+
+	for _, tt := range tests {
+		prompt := confirmPrompt("Continue", tt.defaultNo)
+		prompt.Stdin = io.NopCloser(strings.NewReader(tt.input))
+		prompt.Stdout = nopCloser{io.Discard}
+		got, err := runConfirm(prompt, tt.defaultNo)
+		if err != nil || got != tt.want {
+			t.Errorf("answer %q (defaultNo=%v) = %v, %v; want %v", tt.input, tt.defaultNo, got, err, tt.want)
+		}
+	}
+}
+
+func TestConfirmPrompt_WritesToOutput(t *testing.T) {
+	var buf bytes.Buffer
+	old := Output
+	Output = &buf
+	t.Cleanup(func() { Output = old })
+
+	prompt := confirmPrompt("Apply to prod", true)
+	prompt.Stdin = io.NopCloser(strings.NewReader("\n"))
+	if _, err := runConfirm(prompt, true); err != nil {
+		t.Fatalf("runConfirm() error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Apply to prod") {
+		t.Errorf("prompt not written to Output: %q", buf.String())
+	}
+}
@@ -0,0 +1,270 @@
+package janus
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var testMigrations = map[string]string{
+	"000001_create_a.sql": "-- +migrate UP\nCREATE TABLE a (id INT);\n-- +migrate DOWN\nDROP TABLE a;\n",
+	"000002_create_b.sql": "-- +migrate UP\nCREATE TABLE b (id INT);\n-- +migrate DOWN\nDROP TABLE b;\n",
+	"000003_create_c.sql": "-- +migrate UP\nCREATE TABLE c (id INT);\n-- +migrate DOWN\nDROP TABLE c;\n",
+}
+
+func testFS() fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range testMigrations {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func writeMigrations(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "migrations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range testMigrations {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func checkStatus(t *testing.T, mg *Migrator, version uint, applied int) {
+	t.Helper()
+	s, err := mg.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if s.Version != version || s.Applied != applied || s.Pending != 3-applied || s.Total != 3 || s.Dirty {
+		t.Errorf("Status() = %+v; want version %d with %d applied", s, version, applied)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"no database", Options{MigrationsPath: "."}, "DatabaseURL or DB is required"},
+		{"both databases", Options{DatabaseURL: "sqlite3://x.db", DB: db, MigrationsPath: "."}, "only one"},
+		{"missing dialect", Options{DB: db, MigrationsPath: "."}, "unsupported dialect"},
+		{"no source", Options{DatabaseURL: "sqlite3://x.db"}, "MigrationsPath or FS is required"},
+		{"missing path", Options{DatabaseURL: "sqlite3://x.db", MigrationsPath: "/nonexistent"}, "source"},
+		{"missing FS dir", Options{DatabaseURL: "sqlite3://x.db", FS: testFS(), MigrationsPath: "other"}, "source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v; want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrator_URLAndPath(t *testing.T) {
+	ctx := context.Background()
+	mg, err := New(Options{
+		DatabaseURL:    "sqlite3://" + filepath.Join(t.TempDir(), "test.db"),
+		MigrationsPath: writeMigrations(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	checkStatus(t, mg, 0, 0)
+
+	if err := mg.Up(ctx, 2); err != nil {
+		t.Fatalf("Up(2) error: %v", err)
+	}
+	checkStatus(t, mg, 2, 2)
+
+	if err := mg.Up(ctx, 0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	checkStatus(t, mg, 3, 3)
+	if err := mg.Up(ctx, 0); err != nil {
+		t.Errorf("Up() with nothing pending error: %v", err)
+	}
+
+	if err := mg.Down(ctx, 0); err != nil {
+		t.Fatalf("Down() error: %v", err)
+	}
+	checkStatus(t, mg, 2, 2)
+
+	if err := mg.Goto(ctx, 1); err != nil {
+		t.Fatalf("Goto(1) error: %v", err)
+	}
+	checkStatus(t, mg, 1, 1)
+
+	history, err := mg.History(ctx)
+	if err != nil {
+		t.Fatalf("History() error: %v", err)
+	}
+	want := []Migration{{1, "create_a", true}, {2, "create_b", false}, {3, "create_c", false}}
+	if len(history) != len(want) {
+		t.Fatalf("History() = %+v; want %+v", history, want)
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Errorf("History()[%d] = %+v; want %+v", i, history[i], want[i])
+		}
+	}
+}
+
+func TestMigrator_DBAndFS(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mg, err := New(Options{DB: db, Dialect: DialectSQLite, FS: testFS(), MigrationsPath: "migrations"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := mg.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	checkStatus(t, mg, 3, 3)
+
+	// The caller's handle stays open
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM c").Scan(&n); err != nil {
+		t.Errorf("query after Up: %v", err)
+	}
+}
+
+func TestMigrator_DBStaysOpen(t *testing.T) {
+	db := sql.OpenDB(fakePostgres{})
+	defer func() { _ = db.Close() }()
+
+	mg, err := New(Options{DB: db, Dialect: DialectPostgres, FS: testFS(), MigrationsPath: "migrations"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	// Each operation returns its connection to the caller's pool
+	for i := 0; i < 2; i++ {
+		checkStatus(t, mg, 0, 0)
+	}
+	if err := db.Ping(); err != nil {
+		t.Errorf("Ping() after Status: %v", err)
+	}
+}
+
+func TestMigrator_CancelledContext(t *testing.T) {
+	mg, err := New(Options{
+		DatabaseURL:    "sqlite3://" + filepath.Join(t.TempDir(), "test.db"),
+		MigrationsPath: writeMigrations(t),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := mg.Up(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Up() error = %v; want context.Canceled", err)
+	}
+	if _, err := mg.Status(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Status() error = %v; want context.Canceled", err)
+	}
+
+	// A cancelled operation leaves the migrator usable
+	if err := mg.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	checkStatus(t, mg, 3, 3)
+}
+
+func TestMigrator_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	mg, err := New(Options{
+		DatabaseURL:    "sqlite3://" + filepath.Join(t.TempDir(), "test.db"),
+		MigrationsPath: writeMigrations(t),
+		Logger:         slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Up(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "create_a") {
+		t.Errorf("log = %q; want it to mention the applied migration", buf.String())
+	}
+}
+
+// fakePostgres is a database/sql connector answering the queries the
+// golang-migrate postgres driver makes for Status on an empty database
+type fakePostgres struct{}
+
+func (fakePostgres) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakePostgres) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("fake: not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("fake: not supported") }
+
+func (fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.Contains(query, "CURRENT_DATABASE()"):
+		return &fakeRows{values: []driver.Value{"app"}}, nil
+	case strings.Contains(query, "CURRENT_SCHEMA()"):
+		return &fakeRows{values: []driver.Value{"public"}}, nil
+	case strings.Contains(query, "COUNT(1)"):
+		// The schema_migrations table exists
+		return &fakeRows{values: []driver.Value{int64(1)}}, nil
+	}
+	// No version recorded
+	return &fakeRows{columns: 2}, nil
+}
+
+// fakeRows holds at most one row
+type fakeRows struct {
+	values  []driver.Value
+	columns int
+}
+
+func (r *fakeRows) Columns() []string {
+	return make([]string, max(len(r.values), r.columns))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.values == nil {
+		return io.EOF
+	}
+	copy(dest, r.values)
+	r.values = nil
+	return nil
+}